@@ -0,0 +1,81 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata of the application. They can be set at build time with the linker flags, e.g.
+//
+//	go build -ldflags "-X main.version=1.2.0 -X main.commit=$(git rev-parse HEAD)" ./cmd/api
+//
+// When they are not set, they are read from the build information embedded by the Go toolchain.
+var (
+	version   string
+	commit    string
+	buildTime string
+)
+
+// build holds the build metadata of the running binary.
+var build = readBuildInfo()
+
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// readBuildInfo returns the build metadata, preferring the values set with the linker flags
+// over the ones embedded by the Go toolchain.
+func readBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	embedded, ok := debug.ReadBuildInfo()
+	if ok {
+		// The main module version is "(devel)" when the binary is built from a local checkout.
+		if info.Version == "" && embedded.Main.Version != "(devel)" {
+			info.Version = embedded.Main.Version
+		}
+
+		var modified bool
+		for _, setting := range embedded.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+
+		// Flag the builds made from a working tree with uncommitted changes.
+		if modified && commit == "" && info.Commit != "" {
+			info.Commit += "-dirty"
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+
+	return info
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/katatrina/greenlight/internal/util"
+	"github.com/katatrina/greenlight/internal/validator"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration settings for our application.
+type config struct {
+	port            int
+	env             string
+	logLevel        slog.Level
+	shutdownTimeout time.Duration
+	server          struct {
+		idleTimeout  time.Duration
+		readTimeout  time.Duration
+		writeTimeout time.Duration
+	}
+	db struct {
+		dsn             string
+		maxConns        int
+		minConns        int
+		maxConnIdleTime time.Duration
+		maxConnLifetime time.Duration
+		connectTimeout  time.Duration
+	}
+	smtp struct {
+		host     string
+		port     int
+		username string
+		password string
+		sender   string
+	}
+	// tokens holds the lifetime of each token scope.
+	tokens struct {
+		authenticationTTL time.Duration
+		activationTTL     time.Duration
+		passwordResetTTL  time.Duration
+	}
+	// limiter holds the token-bucket settings of the rate limiter. Anonymous clients and failed
+	// authentications are limited by their IP address, while authenticated users are limited by their user ID.
+	limiter struct {
+		enabled   bool
+		rps       float64
+		burst     int
+		userRPS   float64
+		userBurst int
+	}
+	cors struct {
+		trustedOrigins []string
+	}
+	// tls holds the settings of the HTTPS server. TLS is enabled when a certificate file is provided.
+	tls struct {
+		certFile       string
+		keyFile        string
+		minVersion     string
+		cipherSuites   []string
+		reloadInterval time.Duration
+		redirectPort   int
+	}
+	// cursor holds the secret used to sign the pagination cursors.
+	cursor struct {
+		secret string
+	}
+	// healthcheck holds the settings of the readiness probe.
+	healthcheck struct {
+		timeout   time.Duration
+		checkMail bool
+	}
+	// trash holds the settings of the job purging the deleted movies.
+	trash struct {
+		retention     time.Duration
+		purgeInterval time.Duration
+	}
+	// blob holds the settings of the store of the uploaded files, such as the images of the movies.
+	blob struct {
+		backend  string
+		localDir string
+	}
+}
+
+const (
+	// envPrefix is the prefix of the environment variables holding configuration settings.
+	// For example, the "db-dsn" setting is read from the GREENLIGHT_DB_DSN environment variable.
+	envPrefix = "GREENLIGHT_"
+)
+
+var (
+	// errExitEarly is returned by loadConfig when the program should exit straight away
+	// without an error, for example after printing the effective configuration.
+	errExitEarly = errors.New("exit early")
+
+	// legacyEnvVars maps the environment variables used by older versions to their settings.
+	// They have a lower precedence than the GREENLIGHT_* environment variables.
+	legacyEnvVars = map[string]string{
+		"MAILTRAP_SMTP_USERNAME": "smtp-username",
+		"MAILTRAP_SMTP_PASSWORD": "smtp-password",
+	}
+
+	// secretSettings are redacted when printing the effective configuration.
+	secretSettings = []string{"smtp-password", "cursor-secret"}
+
+	dsnPasswordRX = regexp.MustCompile(`password=\S+`)
+)
+
+// loadConfig reads the configuration settings from the following sources,
+// in increasing order of precedence:
+//
+//  1. the YAML config file given by the -config flag (or the GREENLIGHT_CONFIG environment variable),
+//  2. the GREENLIGHT_* environment variables,
+//  3. the command-line flags.
+//
+// Every setting is identified by its flag name. In the config file, nested keys are joined with a "-",
+// so "db: {dsn: ...}" is the same as "db-dsn: ...". In the environment, the name is upper-cased,
+// its dashes are replaced with underscores and it's prefixed with GREENLIGHT_, e.g. GREENLIGHT_DB_DSN.
+//
+// The merged settings are validated before being returned.
+func loadConfig(args []string, stdout io.Writer) (config, error) {
+	var (
+		cfg            config
+		configFile     string
+		printConfig    bool
+		displayVersion bool
+	)
+
+	fs := flag.NewFlagSet("greenlight", flag.ContinueOnError)
+
+	fs.StringVar(&configFile, "config", os.Getenv(envPrefix+"CONFIG"), "Path to a YAML config file")
+	fs.BoolVar(&printConfig, "print-config", false, "Print the effective configuration (with secrets redacted) and exit")
+	fs.BoolVar(&displayVersion, "version", false, "Print the build metadata and exit")
+
+	fs.IntVar(&cfg.port, "port", 4000, "API server port")
+	fs.StringVar(&cfg.env, "env", "development", "Environment (development|staging|production)")
+	fs.TextVar(&cfg.logLevel, "log-level", slog.LevelInfo, "Minimum log level (debug|info|warn|error)")
+	fs.DurationVar(&cfg.shutdownTimeout, "shutdown-timeout", 30*time.Second, "Grace period for in-flight requests and background tasks on shutdown")
+
+	fs.DurationVar(&cfg.server.idleTimeout, "server-idle-timeout", time.Minute, "HTTP server idle timeout")
+	fs.DurationVar(&cfg.server.readTimeout, "server-read-timeout", 5*time.Second, "HTTP server read timeout")
+	fs.DurationVar(&cfg.server.writeTimeout, "server-write-timeout", 10*time.Second, "HTTP server write timeout")
+
+	fs.StringVar(&cfg.db.dsn, "db-dsn", "", "PostgreSQL DSN")
+	fs.IntVar(&cfg.db.maxConns, "db-max-conns", 25, "PostgreSQL maximum number of connections in the pool")
+	fs.IntVar(&cfg.db.minConns, "db-min-conns", 0, "PostgreSQL minimum number of connections in the pool")
+	fs.DurationVar(&cfg.db.maxConnIdleTime, "db-max-conn-idle-time", 15*time.Minute, "PostgreSQL maximum connection idle time")
+	fs.DurationVar(&cfg.db.maxConnLifetime, "db-max-conn-lifetime", time.Hour, "PostgreSQL maximum connection lifetime")
+	fs.DurationVar(&cfg.db.connectTimeout, "db-connect-timeout", 5*time.Second, "PostgreSQL connection timeout")
+
+	fs.StringVar(&cfg.smtp.host, "smtp-host", "sandbox.smtp.mailtrap.io", "SMTP host")
+	fs.IntVar(&cfg.smtp.port, "smtp-port", 587, "SMTP port")
+	fs.StringVar(&cfg.smtp.username, "smtp-username", "", "SMTP username")
+	fs.StringVar(&cfg.smtp.password, "smtp-password", "", "SMTP password")
+	fs.StringVar(&cfg.smtp.sender, "smtp-sender", "Greenlight <noreply@mail.accounts.greenlight.com>", "SMTP sender")
+
+	fs.DurationVar(&cfg.tokens.authenticationTTL, "token-authentication-ttl", 24*time.Hour, "Lifetime of the authentication tokens")
+	fs.DurationVar(&cfg.tokens.activationTTL, "token-activation-ttl", 3*24*time.Hour, "Lifetime of the activation tokens")
+	fs.DurationVar(&cfg.tokens.passwordResetTTL, "token-password-reset-ttl", 45*time.Minute, "Lifetime of the password reset tokens")
+
+	fs.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable rate limiter")
+	fs.Float64Var(&cfg.limiter.rps, "limiter-rps", 2, "Rate limiter maximum requests per second for anonymous clients and failed authentications, by IP address")
+	fs.IntVar(&cfg.limiter.burst, "limiter-burst", 4, "Rate limiter maximum burst for anonymous clients and failed authentications, by IP address")
+	fs.Float64Var(&cfg.limiter.userRPS, "limiter-user-rps", 10, "Rate limiter maximum requests per second for authenticated users")
+	fs.IntVar(&cfg.limiter.userBurst, "limiter-user-burst", 20, "Rate limiter maximum burst for authenticated users")
+
+	fs.Var((*stringList)(&cfg.cors.trustedOrigins), "cors-trusted-origins", "Trusted CORS origins (space separated)")
+
+	fs.StringVar(&cfg.tls.certFile, "tls-cert-file", "", "TLS certificate file (enables HTTPS)")
+	fs.StringVar(&cfg.tls.keyFile, "tls-key-file", "", "TLS private key file")
+	fs.StringVar(&cfg.tls.minVersion, "tls-min-version", "1.2", "Minimum TLS version (1.2|1.3)")
+	fs.Var((*stringList)(&cfg.tls.cipherSuites), "tls-cipher-suites", "TLS 1.2 cipher suites, by preference (space separated, defaults to Go's secure suites)")
+	fs.DurationVar(&cfg.tls.reloadInterval, "tls-reload-interval", time.Minute, "How often to check the TLS certificate files for changes")
+	fs.IntVar(&cfg.tls.redirectPort, "tls-redirect-port", 0, "Plain HTTP port redirecting to HTTPS (0 disables it)")
+
+	fs.StringVar(&cfg.cursor.secret, "cursor-secret", "", "Secret used to sign the pagination cursors (random if empty)")
+
+	fs.DurationVar(&cfg.healthcheck.timeout, "healthcheck-timeout", 2*time.Second, "Timeout of each readiness check")
+	fs.BoolVar(&cfg.healthcheck.checkMail, "healthcheck-check-mail", false, "Require the mail transport to be reachable for the readiness check")
+
+	fs.DurationVar(&cfg.trash.retention, "trash-retention", 30*24*time.Hour, "How long the deleted movies are kept in the trash before being purged")
+	fs.DurationVar(&cfg.trash.purgeInterval, "trash-purge-interval", time.Hour, "How often to purge the deleted movies")
+
+	fs.StringVar(&cfg.blob.backend, "blob-backend", "local", "Storage backend of the uploaded files (local)")
+	fs.StringVar(&cfg.blob.localDir, "blob-local-dir", "./uploads", "Directory of the uploaded files, for the local backend")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return cfg, err
+	}
+
+	// Print the build metadata before reading any other source, so that it works without a valid configuration.
+	if displayVersion {
+		fmt.Fprintf(stdout, "Version:\t%s\nCommit:\t\t%s\nBuild time:\t%s\nGo version:\t%s\n",
+			build.Version, build.Commit, build.BuildTime, build.GoVersion)
+		return cfg, errExitEarly
+	}
+
+	// Remember which settings have been given on the command line, since they take precedence
+	// over the config file and the environment.
+	setByFlag := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		setByFlag[f.Name] = true
+	})
+
+	// setValue sets a setting from a lower-precedence source, unless it has been given on the command line.
+	setValue := func(source, name, value string) error {
+		if setByFlag[name] || name == "config" || name == "print-config" || name == "version" {
+			return nil
+		}
+
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("%s: unknown setting %q", source, name)
+		}
+
+		err := fs.Set(name, value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid value %q for %s: %w", source, value, name, err)
+		}
+
+		return nil
+	}
+
+	// 1. Apply the settings from the config file.
+	if configFile != "" {
+		settings, err := readConfigFile(configFile)
+		if err != nil {
+			return cfg, err
+		}
+
+		for name, value := range settings {
+			err = setValue("config file "+configFile, name, value)
+			if err != nil {
+				return cfg, err
+			}
+		}
+	}
+
+	// 2. Apply the settings from the environment, the legacy variables first so that
+	// they can be overridden by the GREENLIGHT_* variables.
+	for envVar, name := range legacyEnvVars {
+		if value, ok := os.LookupEnv(envVar); ok {
+			err = setValue("environment variable "+envVar, name, value)
+			if err != nil {
+				return cfg, err
+			}
+		}
+	}
+
+	var envErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		envVar := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+
+		if value, ok := os.LookupEnv(envVar); ok && envErr == nil {
+			envErr = setValue("environment variable "+envVar, f.Name, value)
+		}
+	})
+	if envErr != nil {
+		return cfg, envErr
+	}
+
+	// 3. The command-line flags have already been applied by fs.Parse().
+
+	violations := validateConfig(&cfg)
+	if !violations.Empty() {
+		return cfg, configError(violations)
+	}
+
+	if printConfig {
+		printEffectiveConfig(stdout, fs)
+		return cfg, errExitEarly
+	}
+
+	return cfg, nil
+}
+
+// stringList is a flag.Value holding a space-separated list of strings.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = strings.Fields(value)
+	return nil
+}
+
+// readConfigFile reads a YAML config file, and returns its settings keyed by their flag names.
+func readConfigFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var document map[string]any
+
+	err = yaml.Unmarshal(content, &document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	settings := make(map[string]string)
+	flattenConfigFile(settings, "", document)
+
+	return settings, nil
+}
+
+// flattenConfigFile flattens the nested YAML mappings into settings, by joining the nested keys with a "-".
+// Lists are joined with spaces, like the space-separated flags.
+func flattenConfigFile(settings map[string]string, prefix string, document map[string]any) {
+	for key, value := range document {
+		name := key
+		if prefix != "" {
+			name = prefix + "-" + key
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			flattenConfigFile(settings, name, v)
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			settings[name] = strings.Join(items, " ")
+		case nil:
+			settings[name] = ""
+		default:
+			settings[name] = fmt.Sprint(v)
+		}
+	}
+}
+
+// validateConfig checks the merged configuration settings, keyed by their flag names.
+func validateConfig(cfg *config) validator.Violations {
+	violations := validator.New()
+
+	if cfg.port < 1 || cfg.port > 65535 {
+		violations.AddError("port", "must be between 1 and 65535")
+	}
+
+	if !util.PermittedValue(cfg.env, "development", "staging", "production") {
+		violations.AddError("env", "must be one of development, staging or production")
+	}
+
+	durations := map[string]time.Duration{
+		"shutdown-timeout":         cfg.shutdownTimeout,
+		"server-idle-timeout":      cfg.server.idleTimeout,
+		"server-read-timeout":      cfg.server.readTimeout,
+		"server-write-timeout":     cfg.server.writeTimeout,
+		"db-max-conn-idle-time":    cfg.db.maxConnIdleTime,
+		"db-max-conn-lifetime":     cfg.db.maxConnLifetime,
+		"db-connect-timeout":       cfg.db.connectTimeout,
+		"token-authentication-ttl": cfg.tokens.authenticationTTL,
+		"token-activation-ttl":     cfg.tokens.activationTTL,
+		"token-password-reset-ttl": cfg.tokens.passwordResetTTL,
+		"healthcheck-timeout":      cfg.healthcheck.timeout,
+		"tls-reload-interval":      cfg.tls.reloadInterval,
+		"trash-retention":          cfg.trash.retention,
+		"trash-purge-interval":     cfg.trash.purgeInterval,
+	}
+	for name, duration := range durations {
+		if duration <= 0 {
+			violations.AddError(name, "must be a positive duration")
+		}
+	}
+
+	if cfg.db.dsn == "" {
+		violations.AddError("db-dsn", "must be provided")
+	}
+
+	if cfg.db.maxConns < 1 {
+		violations.AddError("db-max-conns", "must be at least 1")
+	}
+
+	if cfg.db.minConns < 0 || cfg.db.minConns > cfg.db.maxConns {
+		violations.AddError("db-min-conns", "must be between 0 and db-max-conns")
+	}
+
+	if !util.PermittedValue(cfg.blob.backend, "local") {
+		violations.AddError("blob-backend", "must be local")
+	}
+
+	if cfg.blob.backend == "local" && cfg.blob.localDir == "" {
+		violations.AddError("blob-local-dir", "must be provided")
+	}
+
+	if cfg.smtp.host == "" {
+		violations.AddError("smtp-host", "must be provided")
+	}
+
+	if cfg.smtp.port < 1 || cfg.smtp.port > 65535 {
+		violations.AddError("smtp-port", "must be between 1 and 65535")
+	}
+
+	if cfg.smtp.sender == "" {
+		violations.AddError("smtp-sender", "must be provided")
+	}
+
+	if cfg.limiter.enabled {
+		if cfg.limiter.rps <= 0 {
+			violations.AddError("limiter-rps", "must be greater than 0")
+		}
+
+		if cfg.limiter.burst < 1 {
+			violations.AddError("limiter-burst", "must be at least 1")
+		}
+
+		if cfg.limiter.userRPS <= 0 {
+			violations.AddError("limiter-user-rps", "must be greater than 0")
+		}
+
+		if cfg.limiter.userBurst < 1 {
+			violations.AddError("limiter-user-burst", "must be at least 1")
+		}
+	}
+
+	for _, origin := range cfg.cors.trustedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			violations.AddError("cors-trusted-origins", fmt.Sprintf("invalid origin %q, must be in the form scheme://host[:port]", origin))
+			break
+		}
+	}
+
+	if (cfg.tls.certFile == "") != (cfg.tls.keyFile == "") {
+		violations.AddError("tls-key-file", "tls-cert-file and tls-key-file must be provided together")
+	}
+
+	if _, ok := tlsVersions[cfg.tls.minVersion]; !ok {
+		violations.AddError("tls-min-version", "must be one of 1.2 or 1.3")
+	}
+
+	for _, name := range cfg.tls.cipherSuites {
+		if _, ok := cipherSuiteID(name); !ok {
+			violations.AddError("tls-cipher-suites", fmt.Sprintf("unknown or insecure cipher suite %q", name))
+			break
+		}
+	}
+
+	if cfg.tls.redirectPort != 0 {
+		if cfg.tls.certFile == "" {
+			violations.AddError("tls-redirect-port", "requires TLS to be enabled")
+		} else if cfg.tls.redirectPort < 1 || cfg.tls.redirectPort > 65535 || cfg.tls.redirectPort == cfg.port {
+			violations.AddError("tls-redirect-port", "must be between 1 and 65535 and different from port")
+		}
+	}
+
+	return violations
+}
+
+// configError turns the configuration violations into a single error, with one line per setting.
+func configError(violations validator.Violations) error {
+	names := make([]string, 0, len(violations))
+	for name := range violations {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	b.WriteString("invalid configuration:")
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n  %s: %s", name, violations[name])
+	}
+
+	return errors.New(b.String())
+}
+
+// printEffectiveConfig writes the value of every setting, sorted by name, with the secrets redacted.
+// The output can be used as a config file.
+func printEffectiveConfig(w io.Writer, fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" || f.Name == "print-config" || f.Name == "version" {
+			return
+		}
+
+		value := f.Value.String()
+
+		switch {
+		case value == "":
+		case f.Name == "db-dsn":
+			value = redactDSN(value)
+		case slices.Contains(secretSettings, f.Name):
+			value = "xxxxx"
+		}
+
+		fmt.Fprintf(w, "%s: %q\n", f.Name, value)
+	})
+}
+
+// redactDSN hides the password of a PostgreSQL DSN, either in the URL or in the keyword/value format.
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+
+	return dsnPasswordRX.ReplaceAllString(dsn, "password=xxxxx")
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// clearConfigEnv unsets the environment variables read by loadConfig for the duration of the test,
+// so that the tests don't depend on the environment of the host.
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if _, legacy := legacyEnvVars[name]; legacy || strings.HasPrefix(name, envPrefix) {
+			// t.Setenv restores the variable at the end of the test.
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	const configFile = `
+port: 5000
+db:
+  dsn: postgres://file
+smtp:
+  username: file-user
+cors-trusted-origins:
+  - https://a.example.com
+  - https://b.example.com
+`
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		args         []string
+		wantPort     int
+		wantDSN      string
+		wantUsername string
+		wantOrigins  int
+	}{
+		{
+			name:         "config file",
+			wantPort:     5000,
+			wantDSN:      "postgres://file",
+			wantUsername: "file-user",
+			wantOrigins:  2,
+		},
+		{
+			name:         "environment over config file",
+			env:          map[string]string{"GREENLIGHT_PORT": "6000", "GREENLIGHT_CORS_TRUSTED_ORIGINS": "https://c.example.com"},
+			wantPort:     6000,
+			wantDSN:      "postgres://file",
+			wantUsername: "file-user",
+			wantOrigins:  1,
+		},
+		{
+			name:         "flags over environment",
+			env:          map[string]string{"GREENLIGHT_PORT": "6000", "GREENLIGHT_DB_DSN": "postgres://env"},
+			args:         []string{"-port", "7000"},
+			wantPort:     7000,
+			wantDSN:      "postgres://env",
+			wantUsername: "file-user",
+			wantOrigins:  2,
+		},
+		{
+			name:         "flags over legacy environment",
+			env:          map[string]string{"MAILTRAP_SMTP_USERNAME": "legacy-user"},
+			args:         []string{"-smtp-username", "flag-user"},
+			wantPort:     5000,
+			wantDSN:      "postgres://file",
+			wantUsername: "flag-user",
+			wantOrigins:  2,
+		},
+		{
+			name:         "legacy environment over config file",
+			env:          map[string]string{"MAILTRAP_SMTP_USERNAME": "legacy-user"},
+			wantPort:     5000,
+			wantDSN:      "postgres://file",
+			wantUsername: "legacy-user",
+			wantOrigins:  2,
+		},
+		{
+			name:         "environment over legacy environment",
+			env:          map[string]string{"MAILTRAP_SMTP_USERNAME": "legacy-user", "GREENLIGHT_SMTP_USERNAME": "env-user"},
+			wantPort:     5000,
+			wantDSN:      "postgres://file",
+			wantUsername: "env-user",
+			wantOrigins:  2,
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "greenlight.yaml")
+	if err := os.WriteFile(path, []byte(configFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			t.Setenv("GREENLIGHT_CONFIG", path)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := loadConfig(tt.args, io.Discard)
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+
+			if cfg.port != tt.wantPort {
+				t.Errorf("port = %d, want %d", cfg.port, tt.wantPort)
+			}
+			if cfg.db.dsn != tt.wantDSN {
+				t.Errorf("db-dsn = %q, want %q", cfg.db.dsn, tt.wantDSN)
+			}
+			if cfg.smtp.username != tt.wantUsername {
+				t.Errorf("smtp-username = %q, want %q", cfg.smtp.username, tt.wantUsername)
+			}
+			if len(cfg.cors.trustedOrigins) != tt.wantOrigins {
+				t.Errorf("cors-trusted-origins = %v, want %d origins", cfg.cors.trustedOrigins, tt.wantOrigins)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		wantErr string // A part of the expected error message.
+	}{
+		{"missing dsn", nil, nil, "db-dsn: must be provided"},
+		{"invalid port", nil, []string{"-db-dsn", "postgres://flag", "-port", "70000"}, "port: must be between 1 and 65535"},
+		{"invalid environment value", map[string]string{"GREENLIGHT_PORT": "many"}, []string{"-db-dsn", "postgres://flag"}, "environment variable GREENLIGHT_PORT"},
+		{"flag over invalid environment value", map[string]string{"GREENLIGHT_PORT": "many"}, []string{"-db-dsn", "postgres://flag", "-port", "4000"}, ""},
+		{"unknown flag", nil, []string{"-unknown"}, "not defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			_, err := loadConfig(tt.args, io.Discard)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("loadConfig() error = %v, want nil", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadConfig() error = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
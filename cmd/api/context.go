@@ -6,7 +6,8 @@ import (
 )
 
 const (
-	userContextKey = "user"
+	userContextKey      = "user"
+	requestIDContextKey = "request_id"
 )
 
 func (app *application) contextSetUser(ctx *gin.Context, user *db.User) {
@@ -14,10 +15,21 @@ func (app *application) contextSetUser(ctx *gin.Context, user *db.User) {
 }
 
 func (app *application) contextGetUser(ctx *gin.Context) *db.User {
-	user, ok := ctx.MustGet(userContextKey).(*db.User)
+	// The only way a user could be missing is a programming error, like a route
+	// not going through the authenticate middleware, so we panic.
+	user, ok := ctx.Value(userContextKey).(*db.User)
 	if !ok {
 		panic("missing user value in request context")
 	}
 
 	return user
 }
+
+func (app *application) contextSetRequestID(ctx *gin.Context, requestID string) {
+	ctx.Set(requestIDContextKey, requestID)
+}
+
+// contextGetRequestID returns the request ID, or an empty string if it has not been set.
+func (app *application) contextGetRequestID(ctx *gin.Context) string {
+	return ctx.GetString(requestIDContextKey)
+}
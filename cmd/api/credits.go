@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/katatrina/greenlight/internal/db"
+	"github.com/katatrina/greenlight/internal/util"
+	"github.com/katatrina/greenlight/internal/validator"
+)
+
+// A movie can't have more than maxMovieCredits credits.
+const maxMovieCredits = 200
+
+// movieCreditResponse is a credit of a movie, along with the credited person.
+type movieCreditResponse struct {
+	ID            int64       `json:"id"`
+	Person        db.Person   `json:"person"`
+	Role          string      `json:"role"`
+	CharacterName pgtype.Text `json:"character_name"`
+	BillingOrder  int32       `json:"billing_order"`
+}
+
+func newMovieCreditResponses(rows []db.ListMovieCreditsRow) []movieCreditResponse {
+	credits := make([]movieCreditResponse, 0, len(rows))
+	for _, row := range rows {
+		credits = append(credits, movieCreditResponse{
+			ID:            row.MovieCredit.ID,
+			Person:        row.Person,
+			Role:          row.MovieCredit.Role,
+			CharacterName: row.MovieCredit.CharacterName,
+			BillingOrder:  row.MovieCredit.BillingOrder,
+		})
+	}
+
+	return credits
+}
+
+// listMovieCreditsHandler show the cast and crew of a specific movie:
+// the directors first, then the writers and the actors, each in their billing order.
+func (app *application) listMovieCreditsHandler(ctx *gin.Context) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	// Make sure the movie exists, so that we don't return an empty list for a movie that doesn't.
+	_, err = app.store.GetMovie(ctx, movieID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	rows, err := app.store.ListMovieCredits(ctx, movieID)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	rsp := envelope{"credits": newMovieCreditResponses(rows)}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+type movieCreditRequest struct {
+	PersonID      int64   `json:"person_id"`
+	Role          string  `json:"role"`
+	CharacterName *string `json:"character_name"`
+	BillingOrder  int32   `json:"billing_order"`
+}
+
+type replaceMovieCreditsRequest struct {
+	Credits []movieCreditRequest `json:"credits"`
+}
+
+func validateReplaceMovieCreditsRequest(req *replaceMovieCreditsRequest) validator.Violations {
+	violations := validator.New()
+
+	if req.Credits == nil {
+		violations.AddError("credits", "must be provided")
+		return violations
+	}
+
+	if len(req.Credits) > maxMovieCredits {
+		violations.AddError("credits", fmt.Sprintf("must not contain more than %d credits", maxMovieCredits))
+		return violations
+	}
+
+	// A person can have several roles in a movie, but each of them only once.
+	type personRole struct {
+		personID int64
+		role     string
+	}
+	seen := make(map[personRole]bool, len(req.Credits))
+
+	for i, credit := range req.Credits {
+		field := fmt.Sprintf("credits[%d]", i)
+
+		if credit.PersonID < 1 {
+			violations.AddError(field+".person_id", "must be a positive integer")
+		}
+
+		if err := validator.ValidateCreditRole(credit.Role); err != nil {
+			violations.AddError(field+".role", err.Error())
+		}
+
+		if credit.CharacterName != nil {
+			if credit.Role != "actor" {
+				violations.AddError(field+".character_name", "must only be provided for an actor")
+			} else if err := validator.ValidateCreditCharacterName(*credit.CharacterName); err != nil {
+				violations.AddError(field+".character_name", err.Error())
+			}
+		}
+
+		if err := validator.ValidateCreditBillingOrder(credit.BillingOrder); err != nil {
+			violations.AddError(field+".billing_order", err.Error())
+		}
+
+		key := personRole{personID: credit.PersonID, role: credit.Role}
+		if seen[key] {
+			violations.AddError(field, "must not credit the same person with the same role twice")
+		}
+		seen[key] = true
+	}
+
+	return violations
+}
+
+// replaceMovieCreditsHandler replace the whole cast and crew of a specific movie.
+func (app *application) replaceMovieCreditsHandler(ctx *gin.Context) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	var req replaceMovieCreditsRequest
+
+	// Parse the request body.
+	err = app.readJSON(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate the request body.
+	violations := validateReplaceMovieCreditsRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	credits := make([]db.CreateMovieCreditParams, 0, len(req.Credits))
+	for _, credit := range req.Credits {
+		credits = append(credits, db.CreateMovieCreditParams{
+			PersonID: credit.PersonID,
+			Role:     credit.Role,
+			CharacterName: pgtype.Text{
+				String: util.GetNullableString(credit.CharacterName),
+				Valid:  credit.CharacterName != nil,
+			},
+			BillingOrder: credit.BillingOrder,
+		})
+	}
+
+	rows, err := app.store.ReplaceMovieCreditsTx(ctx, db.ReplaceMovieCreditsTxParams{
+		MovieID: movieID,
+		Credits: credits,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			app.notFoundResponse(ctx)
+		// The movie exists, so it must be one of the people which doesn't.
+		case db.ErrorCode(err) == db.ForeignKeyViolation:
+			violations.AddError("credits", "must only credit existing people")
+			app.failedValidationResponse(ctx, violations)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	rsp := envelope{"credits": newMovieCreditResponses(rows)}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
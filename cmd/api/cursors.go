@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/katatrina/greenlight/internal/db"
+)
+
+var (
+	errInvalidCursor = errors.New("invalid cursor")
+)
+
+// movieCursor points at a movie in a list sorted by a given key, and is used for keyset pagination.
+//
+// It holds the value of the sort key of that movie (Int for the integer keys, Float for the rating and the relevance,
+// Text for the title) and its id as a tie-breaker. A Backward cursor returns the movies before the one it points at.
+// Fuzzy tells whether the title of the list is searched with the fuzzy search, and Filters is the hash of the filters
+// of the list, since the cursor is only valid for them.
+type movieCursor struct {
+	Sort     string  `json:"s"`
+	ID       int64   `json:"i"`
+	Int      int32   `json:"n,omitempty"`
+	Float    float64 `json:"f,omitempty"`
+	Text     string  `json:"t,omitempty"`
+	Backward bool    `json:"b,omitempty"`
+	Fuzzy    bool    `json:"z,omitempty"`
+	Filters  string  `json:"h"`
+}
+
+// newMovieCursor creates a cursor pointing at the given movie in a list with the given filters, sorted by sort.
+func newMovieCursor(sort string, filters *movieFilters, row db.ListMoviesRow, backward bool) movieCursor {
+	movie := row.Movie
+	cursor := movieCursor{
+		Sort:     sort,
+		ID:       movie.ID,
+		Backward: backward,
+		Fuzzy:    filters.fuzzy,
+		Filters:  filters.hash(),
+	}
+
+	switch strings.TrimPrefix(sort, "-") {
+	case "title":
+		cursor.Text = movie.Title
+	case "publishYear":
+		cursor.Int = movie.PublishYear
+	case "runtime":
+		cursor.Int = int32(movie.Runtime)
+	case "rating":
+		cursor.Float = movie.AverageRating
+	case "relevance":
+		cursor.Float = row.MatchScore
+	}
+
+	return cursor
+}
+
+// setListParams sets the cursor of the parameters of ListMovies to the movie the cursor points at.
+func (cursor movieCursor) setListParams(arg *db.ListMoviesParams) {
+	arg.CursorID = pgtype.Int8{Int64: cursor.ID, Valid: true}
+	arg.CursorText = pgtype.Text{String: cursor.Text, Valid: true}
+	arg.CursorInt = pgtype.Int4{Int32: cursor.Int, Valid: true}
+	arg.CursorFloat = pgtype.Float8{Float64: cursor.Float, Valid: true}
+}
+
+// encodeCursor turns the cursor into an opaque string, made of the base64-encoded JSON cursor
+// and its HMAC-SHA256 signature, so that the clients can't forge cursors.
+func (app *application) encodeCursor(cursor movieCursor) string {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		// A movieCursor can always be encoded.
+		panic(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(app.config.cursor.secret))
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// decodeCursor verifies the signature of an encoded cursor and decodes it.
+func (app *application) decodeCursor(encoded string) (movieCursor, error) {
+	var cursor movieCursor
+
+	encodedPayload, encodedSignature, found := strings.Cut(encoded, ".")
+	if !found {
+		return cursor, errInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+
+	mac := hmac.New(sha256.New, []byte(app.config.cursor.secret))
+	mac.Write(payload)
+
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return cursor, errInvalidCursor
+	}
+
+	err = json.Unmarshal(payload, &cursor)
+	if err != nil || cursor.ID < 1 {
+		return cursor, errInvalidCursor
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/katatrina/greenlight/internal/db"
+)
+
+func newTestCursorApp(secret string) *application {
+	app := &application{}
+	app.config.cursor.secret = secret
+
+	return app
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	app := newTestCursorApp("secret")
+
+	tests := []struct {
+		name   string
+		cursor movieCursor
+	}{
+		{"id", movieCursor{Sort: "id", ID: 42, Filters: "h"}},
+		{"title", movieCursor{Sort: "-title", ID: 7, Text: "The Matrix", Filters: "h"}},
+		{"integer key", movieCursor{Sort: "publishYear", ID: 3, Int: 1999, Backward: true, Filters: "h"}},
+		{"float key", movieCursor{Sort: "relevance", ID: 9, Float: 0.0607927, Fuzzy: true, Filters: "h"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := app.decodeCursor(app.encodeCursor(tt.cursor))
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+
+			if got != tt.cursor {
+				t.Errorf("decodeCursor() = %+v, want %+v", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsInvalidCursors(t *testing.T) {
+	app := newTestCursorApp("secret")
+	encoded := app.encodeCursor(movieCursor{Sort: "id", ID: 42, Filters: "h"})
+	payload, signature, _ := strings.Cut(encoded, ".")
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"id","i":1,"h":"h"}`))
+	zeroID := app.encodeCursor(movieCursor{Sort: "id"})
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"empty", ""},
+		{"without signature", payload},
+		{"forged payload", forged + "." + signature},
+		{"truncated signature", payload + "." + signature[:len(signature)-2]},
+		{"invalid base64", payload + ".!!!"},
+		{"other secret", newTestCursorApp("other").encodeCursor(movieCursor{Sort: "id", ID: 42, Filters: "h"})},
+		{"no movie", zeroID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := app.decodeCursor(tt.encoded)
+			if !errors.Is(err, errInvalidCursor) {
+				t.Errorf("decodeCursor() error = %v, want %v", err, errInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestNewMovieCursor(t *testing.T) {
+	filters := &movieFilters{Title: "matrix", Genres: []string{"sci-fi"}, fuzzy: true}
+	row := db.ListMoviesRow{
+		Movie: db.Movie{
+			ID:            5,
+			Title:         "The Matrix",
+			PublishYear:   1999,
+			Runtime:       136,
+			AverageRating: 8.5,
+		},
+		MatchScore: 0.75,
+	}
+
+	tests := []struct {
+		sort string
+		want movieCursor
+	}{
+		{"id", movieCursor{Sort: "id", ID: 5}},
+		{"-title", movieCursor{Sort: "-title", ID: 5, Text: "The Matrix"}},
+		{"publishYear", movieCursor{Sort: "publishYear", ID: 5, Int: 1999}},
+		{"-runtime", movieCursor{Sort: "-runtime", ID: 5, Int: 136}},
+		{"rating", movieCursor{Sort: "rating", ID: 5, Float: 8.5}},
+		{"relevance", movieCursor{Sort: "relevance", ID: 5, Float: 0.75}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			tt.want.Backward = true
+			tt.want.Fuzzy = true
+			tt.want.Filters = filters.hash()
+
+			got := newMovieCursor(tt.sort, filters, row, true)
+			if got != tt.want {
+				t.Errorf("newMovieCursor() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMovieFiltersHash(t *testing.T) {
+	base := movieFilters{Title: "matrix", Genres: []string{"sci-fi"}, GenresMode: "all", userID: 1}
+	yearMin := int32(1990)
+	watched := true
+
+	tests := []struct {
+		name   string
+		modify func(f *movieFilters)
+		same   bool
+	}{
+		{"same filters", func(f *movieFilters) {}, true},
+		{"other title", func(f *movieFilters) { f.Title = "alien" }, false},
+		{"other genres", func(f *movieFilters) { f.Genres = []string{"drama"} }, false},
+		{"any genre", func(f *movieFilters) { f.GenresMode = "any" }, false},
+		{"year bound", func(f *movieFilters) { f.YearMin = &yearMin }, false},
+		{"watched", func(f *movieFilters) { f.Watched = &watched }, false},
+		{"other user", func(f *movieFilters) { f.userID = 2 }, false},
+		{"fuzzy search", func(f *movieFilters) { f.fuzzy = true }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := base
+			tt.modify(&f)
+
+			if same := f.hash() == base.hash(); same != tt.same {
+				t.Errorf("hash() equal = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
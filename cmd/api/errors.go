@@ -2,21 +2,26 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/katatrina/greenlight/internal/validator"
 )
 
 // logError is a generic helper for logging an error (usually caused by the internal server)
-// along with the current request method and URL as attributes in the log entry.
+// along with the current request method, URL and request ID as attributes in the log entry.
 func (app *application) logError(ctx *gin.Context, err error) {
 	var (
-		method = ctx.Request.Method
-		uri    = ctx.Request.URL
+		method    = ctx.Request.Method
+		uri       = ctx.Request.URL
+		requestID = app.contextGetRequestID(ctx)
 	)
 
-	app.logger.Error(err.Error(), "method", method, "uri", uri)
+	app.logger.Error(err.Error(), "method", method, "uri", uri, "request_id", requestID)
 }
 
 // errorResponse is a generic helper for sending JSON-formatted error
@@ -62,6 +67,13 @@ func (app *application) badRequestResponse(ctx *gin.Context, err error) {
 	app.errorResponse(ctx, http.StatusBadRequest, err.Error())
 }
 
+// unsupportedMediaTypeResponse send a 415 Unsupported Media Type status code and JSON response to the client.
+func (app *application) unsupportedMediaTypeResponse(ctx *gin.Context, supportedTypes ...string) {
+	message := fmt.Sprintf("the content type must be one of %s", strings.Join(supportedTypes, ", "))
+
+	app.errorResponse(ctx, http.StatusUnsupportedMediaType, message)
+}
+
 // failedValidationResponse send a 422 Unprocessable Entity status code and JSON response to the client.
 func (app *application) failedValidationResponse(ctx *gin.Context, violations validator.Violations) {
 	app.errorResponse(ctx, http.StatusUnprocessableEntity, violations)
@@ -74,6 +86,13 @@ func (app *application) editConflictResponse(ctx *gin.Context) {
 	app.errorResponse(ctx, http.StatusConflict, message)
 }
 
+// preconditionFailedResponse send a 412 Precondition Failed status code and JSON response to the client.
+func (app *application) preconditionFailedResponse(ctx *gin.Context) {
+	message := "the record has been modified since you retrieved it, please retrieve it again"
+
+	app.errorResponse(ctx, http.StatusPreconditionFailed, message)
+}
+
 // integrityConstraintViolationResponse send a 409 Conflict status code and JSON response to the client.
 func (app *application) integrityConstraintViolationResponse(ctx *gin.Context, message string) {
 	app.errorResponse(ctx, http.StatusConflict, message)
@@ -116,6 +135,23 @@ func (app *application) notPermittedResponse(ctx *gin.Context) {
 	app.errorResponse(ctx, http.StatusForbidden, message)
 }
 
+// notReviewAuthorResponse send 403 Forbidden status code and a generic error message to the client.
+func (app *application) notReviewAuthorResponse(ctx *gin.Context) {
+	message := "you can only modify your own reviews"
+
+	app.errorResponse(ctx, http.StatusForbidden, message)
+}
+
+// rateLimitExceededResponse sends 429 Too Many Requests status code and a generic error message to the client.
+// The Retry-After header tells the client how many seconds to wait before making a new request.
+func (app *application) rateLimitExceededResponse(ctx *gin.Context, retryAfter time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+	message := "rate limit exceeded"
+
+	app.errorResponse(ctx, http.StatusTooManyRequests, message)
+}
+
 // mismatchedAuthenticatedUserEmailResponse sends 403 Forbidden status code and a generic error message to the client.
 func (app *application) mismatchedAuthenticatedUserEmailResponse(ctx *gin.Context) {
 	message := "the email provided does not match the authenticated user's email"
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katatrina/greenlight/internal/db"
+)
+
+// movieETag returns the entity tag of a version of a movie, which changes whenever the movie is modified.
+func movieETag(movie db.Movie) string {
+	return fmt.Sprintf(`"%d-%d"`, movie.ID, movie.Version)
+}
+
+// etagMatches reports whether the value of an If-Match or If-None-Match header matches the etag.
+//
+// The header holds a comma-separated list of entity tags, or "*" which matches any etag.
+// With weak set, as for If-None-Match, the weak entity tags (W/"...") are compared as if they were strong.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkIfMatch checks the If-Match header of the request against the current etag of the resource.
+// It returns false and sends a 412 Precondition Failed response if the header is provided and doesn't match,
+// which means the client is about to modify a version of the resource that is no longer the current one.
+func (app *application) checkIfMatch(ctx *gin.Context, etag string) bool {
+	header := ctx.GetHeader("If-Match")
+	if header == "" || etagMatches(header, etag, false) {
+		return true
+	}
+
+	app.preconditionFailedResponse(ctx)
+	return false
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/katatrina/greenlight/internal/db"
+)
+
+func TestEtagMatches(t *testing.T) {
+	const etag = `"abc"`
+
+	tests := []struct {
+		name   string
+		header string
+		weak   bool
+		want   bool
+	}{
+		{"same etag", `"abc"`, false, true},
+		{"other etag", `"abd"`, false, false},
+		{"any etag", `*`, false, true},
+		{"one of a list", `"xyz", "abc"`, false, true},
+		{"none of a list", `"xyz","uvw"`, false, false},
+		{"weak etag with strong comparison", `W/"abc"`, false, false},
+		{"weak etag with weak comparison", `W/"abc"`, true, true},
+		{"weak etag of a list", `"xyz", W/"abc"`, true, true},
+		{"unquoted etag", `abc`, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.header, etag, tt.weak); got != tt.want {
+				t.Errorf("etagMatches(%q, %q, %v) = %v, want %v", tt.header, etag, tt.weak, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMovieETag(t *testing.T) {
+	movie := db.Movie{
+		ID:            1,
+		Title:         "Alien",
+		Runtime:       117,
+		Genres:        []string{"horror", "sci-fi"},
+		PublishYear:   1979,
+		Version:       3,
+		CreatedAt:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		AverageRating: 8.5,
+		ReviewCount:   2,
+	}
+
+	if got, want := movieETag(movie), `"1-3"`; got != want {
+		t.Errorf("movieETag() = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(m *db.Movie)
+		same   bool
+	}{
+		{"same movie", func(m *db.Movie) {}, true},
+		{"new version", func(m *db.Movie) { m.Version++ }, false},
+		{"other movie", func(m *db.Movie) { m.ID = 2 }, false},
+		// The reviews update the rating of a movie without modifying it, so they don't change its etag.
+		{"new rating", func(m *db.Movie) { m.AverageRating = 8 }, true},
+		{"new review", func(m *db.Movie) { m.ReviewCount++ }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := movie
+			tt.modify(&other)
+
+			if same := movieETag(other) == movieETag(movie); same != tt.same {
+				t.Errorf("movieETag() equal = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katatrina/greenlight/internal/db"
+	"github.com/katatrina/greenlight/internal/util"
+	"github.com/katatrina/greenlight/internal/validator"
+)
+
+// The exported movies are sent to the client every exportFlushInterval rows.
+const exportFlushInterval = 100
+
+type exportMoviesRequest struct {
+	movieFilters
+	Sort   string `form:"sort"`
+	Format string `form:"format"`
+}
+
+// validateExportMoviesRequest validates the exportMoviesRequest struct and sets default "fallback" values if necessary.
+func validateExportMoviesRequest(req *exportMoviesRequest) validator.Violations {
+	violations := validator.New()
+
+	validateMovieFilters(violations, &req.movieFilters)
+
+	// If the sort field is not provided, set it to "id".
+	if req.Sort == "" {
+		req.Sort = "id"
+	}
+
+	if !util.PermittedValue(req.Sort, movieSortSafeList...) {
+		violations.AddError("sort", fmt.Sprintf("invalid sort value <%s>", req.Sort))
+	} else if req.Sort == "relevance" && req.Title == "" {
+		violations.AddError("sort", "relevance must be used along with title")
+	}
+
+	// If the format is not provided, set it to "csv".
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+
+	if !util.PermittedValue(req.Format, "csv", "ndjson") {
+		violations.AddError("format", "must be either csv or ndjson")
+	}
+
+	return violations
+}
+
+// exportMoviesHandler download all the movies matching the same filters as listMoviesHandler,
+// as a CSV file (in the format accepted by importMoviesHandler) or as NDJSON.
+//
+// The movies are streamed from the database to the client as they are read.
+func (app *application) exportMoviesHandler(ctx *gin.Context) {
+	var req exportMoviesRequest
+
+	// Parse query parameters
+	err := app.readQueryParams(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate query parameters
+	violations := validateExportMoviesRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	// The genres may be given by their names or aliases.
+	if !app.normalizeMovieFilters(ctx, &req.movieFilters) {
+		return
+	}
+
+	// Find out whether the title must be searched with the fuzzy search.
+	if req.Title != "" {
+		_, err = app.countMovies(ctx, &req.movieFilters)
+		if err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+	}
+
+	contentType := csvContentType
+	if req.Format == "ndjson" {
+		contentType = ndjsonContentType
+	}
+
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="movies.%s"`, req.Format))
+
+	// The export can last longer than the write timeout of the server,
+	// so we extend the deadline each time a part of it is sent instead.
+	rc := http.NewResponseController(ctx.Writer)
+	buf := bufio.NewWriter(ctx.Writer)
+	flush := func() error {
+		if err := rc.SetWriteDeadline(time.Now().Add(app.config.server.writeTimeout)); err != nil {
+			return err
+		}
+
+		if err := buf.Flush(); err != nil {
+			return err
+		}
+
+		return rc.Flush()
+	}
+
+	var writeMovie func(movie db.Movie) error
+	if req.Format == "csv" {
+		csvWriter := csv.NewWriter(buf)
+		err = csvWriter.Write([]string{"id", "title", "publish_year", "runtime", "genres", "version", "created_at", "average_rating", "review_count"})
+		if err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+
+		writeMovie = func(movie db.Movie) error {
+			csvWriter.Write([]string{
+				strconv.FormatInt(movie.ID, 10),
+				movie.Title,
+				strconv.FormatInt(int64(movie.PublishYear), 10),
+				strconv.FormatInt(int64(movie.Runtime), 10),
+				strings.Join(movie.Genres, "|"),
+				strconv.FormatInt(int64(movie.Version), 10),
+				movie.CreatedAt.Format(time.RFC3339),
+				strconv.FormatFloat(movie.AverageRating, 'f', -1, 64),
+				strconv.FormatInt(int64(movie.ReviewCount), 10),
+			})
+			csvWriter.Flush()
+
+			return csvWriter.Error()
+		}
+	} else {
+		encoder := json.NewEncoder(buf)
+		writeMovie = func(movie db.Movie) error {
+			return encoder.Encode(movie)
+		}
+	}
+
+	var exported int
+	arg := db.ListMoviesParams{MovieFilters: req.params()}
+	arg.SortKey, arg.Descending = movieSortKey(req.Sort)
+
+	err = app.store.ExportMovies(ctx, arg, func(movie db.Movie) error {
+		if err := writeMovie(movie); err != nil {
+			return err
+		}
+
+		exported++
+		if exported%exportFlushInterval == 0 {
+			return flush()
+		}
+
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		// Once a part of the export has been sent, we can't send an error response anymore,
+		// so the client gets a truncated file.
+		if ctx.Writer.Written() {
+			app.logError(ctx, err)
+			return
+		}
+
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		app.serverErrorResponse(ctx, err)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/katatrina/greenlight/internal/db"
+	"github.com/katatrina/greenlight/internal/util"
+	"github.com/katatrina/greenlight/internal/validator"
+)
+
+// movieFilters holds the query parameters which filter the movie lists and exports.
+type movieFilters struct {
+	Title         string     `form:"title"`
+	Genres        []string   `form:"genres"`
+	GenresMode    string     `form:"genres_mode"`
+	ExcludeGenres []string   `form:"exclude_genres"`
+	YearMin       *int32     `form:"year_min"`
+	YearMax       *int32     `form:"year_max"`
+	RuntimeMin    *int32     `form:"runtime_min"`
+	RuntimeMax    *int32     `form:"runtime_max"`
+	CreatedAfter  *time.Time `form:"created_after"`
+	CreatedBefore *time.Time `form:"created_before"`
+	PersonID      *int64     `form:"person_id"`
+	InWatchlist   *bool      `form:"in_watchlist"`
+	Watched       *bool      `form:"watched"`
+
+	// userID is the ID of the authenticated user, to whom the watchlist and watched filters are relative.
+	userID int64
+
+	// fuzzy tells whether the title is searched with the fuzzy search rather than the full-text search.
+	fuzzy bool
+}
+
+// splitListParam splits a comma-separated query parameter, such as the genres, into a slice.
+// If the values are not provided, or are empty, an empty slice is returned.
+func splitListParam(values []string) []string {
+	if values == nil || values[0] == "" {
+		return []string{}
+	}
+
+	return strings.Split(values[0], ",")
+}
+
+// validateMovieFilters validates the movieFilters struct and sets default "fallback" values if necessary.
+func validateMovieFilters(violations validator.Violations, f *movieFilters) {
+	f.Genres = splitListParam(f.Genres)
+	f.ExcludeGenres = splitListParam(f.ExcludeGenres)
+
+	// By default, the movies must have all the genres.
+	if f.GenresMode == "" {
+		f.GenresMode = "all"
+	}
+
+	if !util.PermittedValue(f.GenresMode, "all", "any") {
+		violations.AddError("genres_mode", "must be either all or any")
+	}
+
+	if f.YearMin != nil {
+		if err := validator.ValidateMovieYear(*f.YearMin); err != nil {
+			violations.AddError("year_min", err.Error())
+		}
+	}
+
+	if f.YearMax != nil {
+		if err := validator.ValidateMovieYear(*f.YearMax); err != nil {
+			violations.AddError("year_max", err.Error())
+		}
+	}
+
+	if f.YearMin != nil && f.YearMax != nil && *f.YearMin > *f.YearMax {
+		violations.AddError("year_max", "must be greater than or equal to year_min")
+	}
+
+	if f.RuntimeMin != nil {
+		if err := validator.ValidateMovieRuntime(*f.RuntimeMin); err != nil {
+			violations.AddError("runtime_min", err.Error())
+		}
+	}
+
+	if f.RuntimeMax != nil {
+		if err := validator.ValidateMovieRuntime(*f.RuntimeMax); err != nil {
+			violations.AddError("runtime_max", err.Error())
+		}
+	}
+
+	if f.RuntimeMin != nil && f.RuntimeMax != nil && *f.RuntimeMin > *f.RuntimeMax {
+		violations.AddError("runtime_max", "must be greater than or equal to runtime_min")
+	}
+
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && f.CreatedAfter.After(*f.CreatedBefore) {
+		violations.AddError("created_before", "must be later than or equal to created_after")
+	}
+
+	if f.PersonID != nil && *f.PersonID < 1 {
+		violations.AddError("person_id", "must be a positive integer")
+	}
+}
+
+// normalizeMovieFilters replaces the genres of the filters by the slugs of the genres of the catalogue,
+// and makes the watchlist and watched filters relative to the authenticated user.
+// If it fails, an error response is sent and false is returned.
+func (app *application) normalizeMovieFilters(ctx *gin.Context, f *movieFilters) bool {
+	var ok bool
+
+	f.userID = app.contextGetUser(ctx).ID
+
+	f.Genres, ok = app.normalizeGenres(ctx, f.Genres)
+	if !ok {
+		return false
+	}
+
+	f.ExcludeGenres, ok = app.normalizeGenres(ctx, f.ExcludeGenres)
+
+	return ok
+}
+
+// params returns the arguments of filtered_movies for the filters.
+func (f *movieFilters) params() db.MovieFilters {
+	return db.MovieFilters{
+		Title:         f.Title,
+		Fuzzy:         f.fuzzy,
+		Genres:        f.Genres,
+		GenresAny:     f.GenresMode == "any",
+		ExcludeGenres: f.ExcludeGenres,
+		YearMin:       nullableInt4(f.YearMin),
+		YearMax:       nullableInt4(f.YearMax),
+		RuntimeMin:    nullableInt4(f.RuntimeMin),
+		RuntimeMax:    nullableInt4(f.RuntimeMax),
+		CreatedAfter:  nullableTimestamptz(f.CreatedAfter),
+		CreatedBefore: nullableTimestamptz(f.CreatedBefore),
+		PersonID:      nullableInt8(f.PersonID),
+		UserID:        f.userID,
+		InWatchlist:   nullableBool(f.InWatchlist),
+		Watched:       nullableBool(f.Watched),
+	}
+}
+
+// hash returns a digest of the filters, which binds the cursors of a list to its filters.
+func (f *movieFilters) hash() string {
+	payload, err := json.Marshal(f.params())
+	if err != nil {
+		// The filters can always be encoded.
+		panic(err)
+	}
+
+	sum := sha256.Sum256(payload)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// countMovies counts the movies matching the filters.
+// If no title matches the full-text search, the fuzzy search is enabled in the filters, which are counted again.
+func (app *application) countMovies(ctx context.Context, f *movieFilters) (int64, error) {
+	f.fuzzy = false
+
+	count, err := app.store.CountMoviesWithFilters(ctx, db.CountMoviesWithFiltersParams(f.params()))
+	if err != nil || count > 0 || f.Title == "" {
+		return count, err
+	}
+
+	f.fuzzy = true
+
+	return app.store.CountMoviesWithFilters(ctx, db.CountMoviesWithFiltersParams(f.params()))
+}
+
+func nullableInt4(value *int32) pgtype.Int4 {
+	return pgtype.Int4{
+		Int32: util.GetNullableInt32(value),
+		Valid: value != nil,
+	}
+}
+
+func nullableInt8(value *int64) pgtype.Int8 {
+	if value == nil {
+		return pgtype.Int8{}
+	}
+
+	return pgtype.Int8{Int64: *value, Valid: true}
+}
+
+func nullableBool(value *bool) pgtype.Bool {
+	if value == nil {
+		return pgtype.Bool{}
+	}
+
+	return pgtype.Bool{Bool: *value, Valid: true}
+}
+
+func nullableTimestamptz(value *time.Time) pgtype.Timestamptz {
+	if value == nil {
+		return pgtype.Timestamptz{}
+	}
+
+	return pgtype.Timestamptz{Time: *value, Valid: true}
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/katatrina/greenlight/internal/db"
+	"github.com/katatrina/greenlight/internal/util"
+	"github.com/katatrina/greenlight/internal/validator"
+)
+
+// The genres of the catalogue are cached for genreCacheTTL, since they are needed by most of the movie requests,
+// but rarely change. The cache of an instance is cleared when the genres are modified through it.
+const genreCacheTTL = time.Minute
+
+// genreResolver maps the slugs and the aliases of the genres of the catalogue to their slugs.
+type genreResolver map[string]string
+
+func newGenreResolver(genres []db.Genre) genreResolver {
+	resolver := make(genreResolver, len(genres))
+	for _, genre := range genres {
+		for _, alias := range genre.Aliases {
+			resolver[alias] = genre.Slug
+		}
+	}
+
+	// A slug always wins over an alias.
+	for _, genre := range genres {
+		resolver[genre.Slug] = genre.Slug
+	}
+
+	return resolver
+}
+
+// resolve replaces the genres of a movie by the slugs of the genres of the catalogue they refer to.
+// The genres are compared case-insensitively, by their slugs.
+func (r genreResolver) resolve(genres []string) ([]string, error) {
+	slugs := make([]string, 0, len(genres))
+	var unknown []string
+
+	for _, genre := range genres {
+		slug, ok := r[util.Slugify(genre)]
+		if !ok {
+			unknown = append(unknown, genre)
+			continue
+		}
+
+		// Two different names may refer to the same genre.
+		if slices.Contains(slugs, slug) {
+			return nil, errors.New("must not contain duplicate values")
+		}
+
+		slugs = append(slugs, slug)
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("must only contain genres of the catalogue, unknown genres: %s", strings.Join(unknown, ", "))
+	}
+
+	return slugs, nil
+}
+
+// normalize is like resolve, for the genres used to filter the movies.
+// The unknown genres are only slugified, so that they don't match any movie.
+func (r genreResolver) normalize(genres []string) []string {
+	slugs := make([]string, 0, len(genres))
+	for _, genre := range genres {
+		slug, ok := r[util.Slugify(genre)]
+		if !ok {
+			slug = util.Slugify(genre)
+		}
+
+		slugs = append(slugs, slug)
+	}
+
+	return slugs
+}
+
+// genreCache holds the genreResolver built from the genres of the catalogue.
+type genreCache struct {
+	mu        sync.Mutex
+	resolver  genreResolver
+	expiresAt time.Time
+	// generation is incremented by each invalidation, so that a resolver loaded before it isn't cached.
+	generation uint64
+}
+
+// cached returns the cached genreResolver if it hasn't expired, along with the current generation.
+func (c *genreCache) cached(now time.Time) (genreResolver, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resolver != nil && now.Before(c.expiresAt) {
+		return c.resolver, c.generation
+	}
+
+	return nil, c.generation
+}
+
+// store caches the resolver loaded during the given generation, unless the genres have been invalidated since.
+func (c *genreCache) store(resolver genreResolver, generation uint64, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+
+	c.resolver = resolver
+	c.expiresAt = now.Add(genreCacheTTL)
+}
+
+// invalidate clears the cached genreResolver.
+func (c *genreCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resolver = nil
+	c.generation++
+}
+
+// genreResolver returns the cached genreResolver, which is rebuilt from the database once it has expired.
+// The lock isn't held during the database round-trip, so that a slow query doesn't block the other requests;
+// concurrent requests may then load the genres at the same time.
+func (app *application) genreResolver(ctx context.Context) (genreResolver, error) {
+	resolver, generation := app.genres.cached(time.Now())
+	if resolver != nil {
+		return resolver, nil
+	}
+
+	genres, err := app.store.ListGenres(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver = newGenreResolver(genres)
+	app.genres.store(resolver, generation, time.Now())
+
+	return resolver, nil
+}
+
+// invalidateGenres clears the cached genreResolver after the genres of the catalogue have been modified.
+func (app *application) invalidateGenres() {
+	app.genres.invalidate()
+}
+
+// resolveGenres replaces the genres of a movie by the slugs of the genres of the catalogue.
+// If they can't be resolved, an error response is sent and false is returned.
+func (app *application) resolveGenres(ctx *gin.Context, genres []string) ([]string, bool) {
+	resolver, err := app.genreResolver(ctx)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return nil, false
+	}
+
+	slugs, err := resolver.resolve(genres)
+	if err != nil {
+		violations := validator.New()
+		violations.AddError("genres", err.Error())
+		app.failedValidationResponse(ctx, violations)
+		return nil, false
+	}
+
+	return slugs, true
+}
+
+// normalizeGenres replaces the genres used to filter the movies by the slugs of the genres of the catalogue.
+// If the genres of the catalogue can't be retrieved, an error response is sent and false is returned.
+func (app *application) normalizeGenres(ctx *gin.Context, genres []string) ([]string, bool) {
+	if len(genres) == 0 {
+		return genres, true
+	}
+
+	resolver, err := app.genreResolver(ctx)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return nil, false
+	}
+
+	return resolver.normalize(genres), true
+}
+
+// checkGenreNames adds a violation for each slug or alias which already refers to another genre than the given one.
+func checkGenreNames(violations validator.Violations, resolver genreResolver, genreSlug string, slug *string, aliases []string) {
+	if slug != nil {
+		if owner, ok := resolver[*slug]; ok && owner != genreSlug {
+			violations.AddError("slug", fmt.Sprintf("is already used by the %s genre", owner))
+		}
+	}
+
+	for _, alias := range aliases {
+		if owner, ok := resolver[alias]; ok && owner != genreSlug {
+			violations.AddError("aliases", fmt.Sprintf("%s is already used by the %s genre", alias, owner))
+			return
+		}
+	}
+}
+
+type genreResponse struct {
+	db.Genre
+	MovieCount int64 `json:"movie_count"`
+}
+
+// listGenresHandler show the genres of the catalogue, along with the number of movies of each genre.
+func (app *application) listGenresHandler(ctx *gin.Context) {
+	rows, err := app.store.ListGenresWithCounts(ctx)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	genres := make([]genreResponse, 0, len(rows))
+	for _, row := range rows {
+		genres = append(genres, genreResponse{Genre: row.Genre, MovieCount: row.MovieCount})
+	}
+
+	rsp := envelope{"genres": genres}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+type createGenreRequest struct {
+	Name    string   `json:"name"`
+	Slug    *string  `json:"slug"`
+	Aliases []string `json:"aliases"`
+}
+
+// validateCreateGenreRequest validates the createGenreRequest struct and derives the slug from the name if necessary.
+func validateCreateGenreRequest(req *createGenreRequest) validator.Violations {
+	violations := validator.New()
+
+	if err := validator.ValidateGenreName(req.Name); err != nil {
+		violations.AddError("name", err.Error())
+	}
+
+	if req.Slug == nil {
+		slug := util.Slugify(req.Name)
+		req.Slug = &slug
+	}
+
+	if err := validator.ValidateGenreSlug(*req.Slug); err != nil {
+		violations.AddError("slug", err.Error())
+	}
+
+	if err := validator.ValidateGenreAliases(req.Aliases); err != nil {
+		violations.AddError("aliases", err.Error())
+	}
+
+	return violations
+}
+
+// createGenreHandler add a genre to the catalogue.
+func (app *application) createGenreHandler(ctx *gin.Context) {
+	var req createGenreRequest
+
+	// Parse the request body.
+	err := app.readJSON(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate the request body.
+	violations := validateCreateGenreRequest(&req)
+	if violations.Empty() {
+		resolver, err := app.genreResolver(ctx)
+		if err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+
+		checkGenreNames(violations, resolver, "", req.Slug, req.Aliases)
+	}
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	if req.Aliases == nil {
+		req.Aliases = []string{}
+	}
+
+	genre, err := app.store.CreateGenre(ctx, db.CreateGenreParams{
+		Slug:    *req.Slug,
+		Name:    req.Name,
+		Aliases: req.Aliases,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation && db.IsContainErrorMessage(err, "genres_slug_key") {
+			app.integrityConstraintViolationResponse(ctx, "a genre with this slug already exists")
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+	app.invalidateGenres()
+
+	headers := make(map[string]string)
+	headers["Location"] = "/v1/genres/" + genre.Slug
+
+	rsp := envelope{"genre": genre}
+	app.writeJSON(ctx, http.StatusCreated, rsp, headers)
+}
+
+// getGenre retrieve the genre identified by the "slug" URL parameter.
+// If it doesn't exist, an error response is sent and false is returned.
+func (app *application) getGenre(ctx *gin.Context) (db.Genre, bool) {
+	genre, err := app.store.GetGenre(ctx, ctx.Param("slug"))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return db.Genre{}, false
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return db.Genre{}, false
+	}
+
+	return genre, true
+}
+
+type updateGenreRequest struct {
+	Name    *string  `json:"name"`
+	Slug    *string  `json:"slug"`
+	Aliases []string `json:"aliases"`
+}
+
+func validateUpdateGenreRequest(req *updateGenreRequest) validator.Violations {
+	violations := validator.New()
+
+	if req.Name != nil {
+		if err := validator.ValidateGenreName(*req.Name); err != nil {
+			violations.AddError("name", err.Error())
+		}
+	}
+
+	if req.Slug != nil {
+		if err := validator.ValidateGenreSlug(*req.Slug); err != nil {
+			violations.AddError("slug", err.Error())
+		}
+	}
+
+	if req.Aliases != nil {
+		if err := validator.ValidateGenreAliases(req.Aliases); err != nil {
+			violations.AddError("aliases", err.Error())
+		}
+	}
+
+	return violations
+}
+
+// updateGenreHandler rename a genre of the catalogue.
+// When its slug changes, the movies of the genre are updated, and the old slug becomes an alias of the genre.
+func (app *application) updateGenreHandler(ctx *gin.Context) {
+	genre, ok := app.getGenre(ctx)
+	if !ok {
+		return
+	}
+
+	var req updateGenreRequest
+
+	// Parse the request body.
+	err := app.readJSON(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate the request body.
+	violations := validateUpdateGenreRequest(&req)
+	if violations.Empty() {
+		resolver, err := app.genreResolver(ctx)
+		if err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+
+		checkGenreNames(violations, resolver, genre.Slug, req.Slug, req.Aliases)
+	}
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	updatedGenre, err := app.store.UpdateGenreTx(ctx, db.UpdateGenreTxParams{
+		UpdateGenreParams: db.UpdateGenreParams{
+			Slug: pgtype.Text{
+				String: util.GetNullableString(req.Slug),
+				Valid:  req.Slug != nil,
+			},
+			Name: pgtype.Text{
+				String: util.GetNullableString(req.Name),
+				Valid:  req.Name != nil,
+			},
+			Aliases: req.Aliases,
+			ID:      genre.ID,
+		},
+		OldSlug: genre.Slug,
+		UserID:  app.contextGetUser(ctx).ID,
+	})
+	if err != nil {
+		switch {
+		// The genre has been merged in the meantime.
+		case errors.Is(err, db.ErrRecordNotFound):
+			app.notFoundResponse(ctx)
+		case db.ErrorCode(err) == db.UniqueViolation && db.IsContainErrorMessage(err, "genres_slug_key"):
+			app.integrityConstraintViolationResponse(ctx, "a genre with this slug already exists")
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+	app.invalidateGenres()
+
+	rsp := envelope{"updated_genre": updatedGenre}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+type mergeGenreRequest struct {
+	Into string `json:"into"`
+}
+
+// mergeGenreHandler merge a genre of the catalogue into another one.
+// The movies of the merged genre get the other genre, and the merged genre becomes one of its aliases.
+func (app *application) mergeGenreHandler(ctx *gin.Context) {
+	source, ok := app.getGenre(ctx)
+	if !ok {
+		return
+	}
+
+	var req mergeGenreRequest
+
+	// Parse the request body.
+	err := app.readJSON(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate the request body.
+	violations := validator.New()
+	if req.Into == "" {
+		violations.AddError("into", "must be provided")
+	} else if req.Into == source.Slug {
+		violations.AddError("into", "must be another genre")
+	}
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	target, err := app.store.GetGenre(ctx, req.Into)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			violations.AddError("into", "must be a genre of the catalogue")
+			app.failedValidationResponse(ctx, violations)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	genre, err := app.store.MergeGenresTx(ctx, db.MergeGenresTxParams{
+		Source: source,
+		Target: target,
+		UserID: app.contextGetUser(ctx).ID,
+	})
+	if err != nil {
+		// One of the genres has been merged in the meantime.
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.editConflictResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+	app.invalidateGenres()
+
+	rsp := envelope{"genre": genre}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/katatrina/greenlight/internal/db"
+)
+
+func TestGenreResolver(t *testing.T) {
+	resolver := newGenreResolver([]db.Genre{
+		{Slug: "sci-fi", Aliases: []string{"science-fiction", "scifi"}},
+		{Slug: "drama"},
+		{Slug: "ciencia-ficción"},
+		// An alias which is the slug of another genre is ignored.
+		{Slug: "comedy", Aliases: []string{"drama"}},
+	})
+
+	tests := []struct {
+		name    string
+		genres  []string
+		want    []string
+		wantErr bool
+	}{
+		{"slugs", []string{"sci-fi", "drama"}, []string{"sci-fi", "drama"}, false},
+		{"alias", []string{"Science Fiction", "drama"}, []string{"sci-fi", "drama"}, false},
+		{"alias of a listed genre", []string{"sci-fi", "SciFi"}, nil, true},
+		{"unicode", []string{"Ciencia Ficción"}, []string{"ciencia-ficción"}, false},
+		{"slug over alias", []string{"Drama"}, []string{"drama"}, false},
+		{"unknown genre", []string{"western"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.resolve(tt.genres)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenreCache(t *testing.T) {
+	now := time.Now()
+	resolver := genreResolver{"drama": "drama"}
+
+	tests := []struct {
+		name  string
+		setup func(c *genreCache) time.Time // Returns the time of the lookup.
+		want  bool                          // Whether the resolver is cached.
+	}{
+		{
+			name:  "empty",
+			setup: func(c *genreCache) time.Time { return now },
+		},
+		{
+			name: "stored",
+			setup: func(c *genreCache) time.Time {
+				_, generation := c.cached(now)
+				c.store(resolver, generation, now)
+				return now
+			},
+			want: true,
+		},
+		{
+			name: "expired",
+			setup: func(c *genreCache) time.Time {
+				_, generation := c.cached(now)
+				c.store(resolver, generation, now)
+				return now.Add(genreCacheTTL)
+			},
+		},
+		{
+			name: "invalidated",
+			setup: func(c *genreCache) time.Time {
+				_, generation := c.cached(now)
+				c.store(resolver, generation, now)
+				c.invalidate()
+				return now
+			},
+		},
+		{
+			name: "invalidated while loading",
+			setup: func(c *genreCache) time.Time {
+				_, generation := c.cached(now)
+				c.invalidate()
+				c.store(resolver, generation, now)
+				return now
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c genreCache
+			at := tt.setup(&c)
+
+			if got, _ := c.cached(at); (got != nil) != tt.want {
+				t.Errorf("cached() = %v, want cached %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,8 +14,92 @@ func (app *application) healthcheckHandler(ctx *gin.Context) {
 		"status": "available",
 		"system_info": map[string]string{
 			"environment": app.config.env,
-			"version":     version,
+			"version":     build.Version,
+			"commit":      build.Commit,
+			"build_time":  build.BuildTime,
+			"go_version":  build.GoVersion,
 		}}
 
 	app.writeJSON(ctx, http.StatusOK, rsp, nil)
 }
+
+// livenessHandler reports that the application is running.
+// It doesn't check any dependency, so that a failing database doesn't get the instance restarted.
+func (app *application) livenessHandler(ctx *gin.Context) {
+	rsp := envelope{"status": "alive"}
+
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+// dependencyCheck is the result of checking a single dependency of the application.
+type dependencyCheck struct {
+	Status   string         `json:"status"`
+	Required bool           `json:"required"`
+	Latency  string         `json:"latency"`
+	Error    string         `json:"error,omitempty"`
+	Details  map[string]any `json:"details,omitempty"`
+}
+
+// checkDependency runs the check function with the configured timeout, and reports its outcome.
+func (app *application) checkDependency(ctx context.Context, required bool, check func(ctx context.Context) error) dependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, app.config.healthcheck.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+
+	result := dependencyCheck{
+		Status:   "up",
+		Required: required,
+		Latency:  time.Since(start).String(),
+	}
+
+	if err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// readinessHandler reports whether the application is able to serve requests.
+//
+// It sends a 503 Service Unavailable status code if any of the required dependencies is down,
+// so that the instance stops receiving traffic.
+func (app *application) readinessHandler(ctx *gin.Context) {
+	checks := make(map[string]dependencyCheck)
+
+	database := app.checkDependency(ctx, true, app.store.Ping)
+
+	// Report how saturated the connection pool is, so that the operators can tell apart
+	// a slow database from an exhausted pool.
+	stat := app.store.Stat()
+	database.Details = map[string]any{
+		"acquired_conns":   stat.AcquiredConns(),
+		"idle_conns":       stat.IdleConns(),
+		"total_conns":      stat.TotalConns(),
+		"max_conns":        stat.MaxConns(),
+		"saturation":       float64(stat.AcquiredConns()) / float64(stat.MaxConns()),
+		"acquire_duration": stat.AcquireDuration().String(),
+	}
+	checks["database"] = database
+
+	if app.config.healthcheck.checkMail {
+		checks["mail"] = app.checkDependency(ctx, true, app.mailer.Ping)
+	}
+
+	status, statusCode := "available", http.StatusOK
+	for _, check := range checks {
+		if check.Required && check.Status != "up" {
+			status, statusCode = "unavailable", http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	rsp := envelope{
+		"status": status,
+		"checks": checks,
+	}
+
+	app.writeJSON(ctx, statusCode, rsp, nil)
+}
@@ -9,6 +9,8 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/katatrina/greenlight/internal/mailer"
+	"github.com/katatrina/greenlight/internal/validator"
 )
 
 type envelope map[string]any
@@ -17,9 +19,14 @@ type envelope map[string]any
 // then convert it into an integer and return it.
 // If the parameter couldn't be converted, or is less than 1, return 0 and an error.
 func (app *application) readIDParam(ctx *gin.Context) (int64, error) {
-	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	return app.readNamedIDParam(ctx, "id")
+}
+
+// readNamedIDParam is like readIDParam, but for an ID URL parameter with another name, such as "review_id".
+func (app *application) readNamedIDParam(ctx *gin.Context, name string) (int64, error) {
+	id, err := strconv.ParseInt(ctx.Param(name), 10, 64)
 	if err != nil || id < 1 {
-		return 0, errors.New("invalid id parameter")
+		return 0, fmt.Errorf("invalid %s parameter", name)
 	}
 
 	return id, nil
@@ -117,8 +124,20 @@ func (app *application) readQueryParams(ctx *gin.Context, destination any) error
 }
 
 // background runs the fn function in another goroutine.
+//
+// The goroutine is tracked by the application's wait group, so the graceful shutdown
+// can wait for it to complete.
 func (app *application) background(fn func()) {
+	app.wg.Add(1)
+	app.backgroundTasks.Add(1)
+
 	go func() {
+		defer func() {
+			app.backgroundTasks.Add(-1)
+			app.finishedTasks.Add(1)
+			app.wg.Done()
+		}()
+
 		defer func() {
 			if panicVal := recover(); panicVal != nil {
 				app.logger.Error(fmt.Sprintf("%v", panicVal))
@@ -128,3 +147,45 @@ func (app *application) background(fn func()) {
 		fn()
 	}()
 }
+
+// sendEmail sends an email using the given template, and records the outcome in the application metrics.
+// It is meant to be called from within a background task, so it takes the ID of the request which
+// triggered the email rather than the request context, which must not be used once the request is over.
+func (app *application) sendEmail(requestID string, header mailer.EmailHeader, data any, htmlTemplateFile string) {
+	err := app.mailer.SendEmail(header, data, htmlTemplateFile)
+	if err != nil {
+		app.metrics.emailsFailed.Add(1)
+		app.logger.Error(err.Error(), "template", htmlTemplateFile, "request_id", requestID)
+		return
+	}
+
+	app.metrics.emailsSent.Add(1)
+	app.logger.Info("email sent", "template", htmlTemplateFile, "request_id", requestID)
+}
+
+// pageRequest holds the query parameters of the lists which are only paginated.
+type pageRequest struct {
+	Page     *int32 `form:"page"`
+	PageSize *int32 `form:"page_size"`
+}
+
+// validatePageRequest validates the pageRequest struct and sets default "fallback" values if necessary.
+func validatePageRequest(req *pageRequest) validator.Violations {
+	violations := validator.New()
+
+	if req.Page == nil { // If the page is not provided, set it to 1.
+		req.Page = new(int32)
+		*req.Page = 1
+	} else if !(*req.Page >= 1 && *req.Page <= 10_000_000) {
+		violations.AddError("page", "must be betweeen 1 and 10,000,000")
+	}
+
+	if req.PageSize == nil { // If the page_size is not provided, set it to 20.
+		req.PageSize = new(int32)
+		*req.PageSize = 20
+	} else if !(*req.PageSize >= 1 && *req.PageSize <= 100) {
+		violations.AddError("page_size", "must be between 1 and 100")
+	}
+
+	return violations
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/katatrina/greenlight/internal/blob"
+	"github.com/katatrina/greenlight/internal/db"
+	"github.com/katatrina/greenlight/internal/util"
+	"github.com/katatrina/greenlight/internal/validator"
+)
+
+const (
+	multipartContentType = "multipart/form-data"
+
+	// The images are much larger than the JSON bodies accepted by readJSON.
+	maxImageBytes = 10 * 1_048_576
+
+	// The images must be between minImageDimension and maxImageDimension pixels wide and high.
+	minImageDimension = 100
+	maxImageDimension = 6000
+
+	// The thumbnails fit in a thumbnailDimension × thumbnailDimension square.
+	thumbnailDimension = 320
+	thumbnailTimeout   = time.Minute
+)
+
+// imageExtensions maps the permitted types of images, as sniffed from their content, to the extensions of their files.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// movieImageResponse is the metadata of an image of a movie.
+// The keys of its files in the blob store are not exposed, the files are downloaded from the URLs instead.
+type movieImageResponse struct {
+	ID           int64     `json:"id"`
+	MovieID      int64     `json:"movie_id"`
+	Kind         string    `json:"kind"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	CreatedAt    time.Time `json:"created_at"`
+	URL          string    `json:"url"`
+	ThumbnailURL *string   `json:"thumbnail_url"`
+}
+
+func newMovieImageResponse(image db.MovieImage) movieImageResponse {
+	rsp := movieImageResponse{
+		ID:          image.ID,
+		MovieID:     image.MovieID,
+		Kind:        image.Kind,
+		ContentType: image.ContentType,
+		Size:        image.Size,
+		Width:       image.Width,
+		Height:      image.Height,
+		CreatedAt:   image.CreatedAt,
+		URL:         fmt.Sprintf("/v1/movies/%d/images/%d", image.MovieID, image.ID),
+	}
+
+	// The thumbnail is only available once it has been generated.
+	if image.ThumbnailKey.Valid {
+		thumbnailURL := rsp.URL + "?variant=thumbnail"
+		rsp.ThumbnailURL = &thumbnailURL
+	}
+
+	return rsp
+}
+
+// newImageKey returns a new random key for an image of a movie in the blob store.
+func newImageKey(movieID int64, extension string) (string, error) {
+	randomBytes := make([]byte, 16)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("movies/%d/images/%s%s", movieID, hex.EncodeToString(randomBytes), extension), nil
+}
+
+// uploadMovieImageHandler attach a poster or a backdrop image to a specific movie.
+//
+// The image is uploaded as the "image" file of a multipart form, along with its "kind".
+// Its type is sniffed from its content rather than trusted from the request. Its thumbnail
+// is generated in the background, so it's only available a bit later.
+func (app *application) uploadMovieImageHandler(ctx *gin.Context) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	if ctx.ContentType() != multipartContentType {
+		app.unsupportedMediaTypeResponse(ctx, multipartContentType)
+		return
+	}
+
+	// Leave some room for the other parts of the form.
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImageBytes+64*1024)
+
+	// Parse the request body.
+	fileHeader, err := ctx.FormFile("image")
+	if err != nil && !errors.Is(err, http.ErrMissingFile) {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			err = fmt.Errorf("body must not be larger than %d bytes", maxImageBytes)
+		}
+
+		app.badRequestResponse(ctx, err)
+		return
+	}
+	kind := ctx.PostForm("kind")
+
+	// Validate the request body.
+	violations := validator.New()
+
+	if !util.PermittedValue(kind, "poster", "backdrop") {
+		violations.AddError("kind", "must be either poster or backdrop")
+	}
+
+	if fileHeader == nil {
+		violations.AddError("image", "must be provided")
+	} else if fileHeader.Size > maxImageBytes {
+		violations.AddError("image", fmt.Sprintf("must not be larger than %d bytes", maxImageBytes))
+	}
+
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	extension, ok := imageExtensions[contentType]
+	if !ok {
+		violations.AddError("image", "must be a JPEG or PNG image")
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	// Only the header of the image is decoded, so that a huge image is rejected before being decoded.
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		violations.AddError("image", "must be a valid image")
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	if imageConfig.Width < minImageDimension || imageConfig.Height < minImageDimension ||
+		imageConfig.Width > maxImageDimension || imageConfig.Height > maxImageDimension {
+		violations.AddError("image", fmt.Sprintf("must be between %d and %d pixels wide and high", minImageDimension, maxImageDimension))
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	// Make sure the movie exists before storing anything.
+	_, err = app.store.GetMovie(ctx, movieID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	key, err := newImageKey(movieID, extension)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	err = app.blobs.Put(ctx, key, bytes.NewReader(data), contentType)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	movieImage, err := app.store.CreateMovieImage(ctx, db.CreateMovieImageParams{
+		MovieID:     movieID,
+		Kind:        kind,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       int32(imageConfig.Width),
+		Height:      int32(imageConfig.Height),
+		BlobKey:     key,
+	})
+	if err != nil {
+		// Don't leave the file behind without its metadata.
+		app.deleteBlobs(key)
+
+		// The movie has been purged in the meantime.
+		if db.ErrorCode(err) == db.ForeignKeyViolation {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	app.background(func() {
+		err := app.generateThumbnail(movieImage, data)
+		if err != nil {
+			app.logger.Error("failed to generate thumbnail", "image_id", movieImage.ID, "error", err.Error())
+		}
+	})
+
+	rsp := newMovieImageResponse(movieImage)
+
+	headers := make(map[string]string)
+	headers["Location"] = rsp.URL
+
+	app.writeJSON(ctx, http.StatusCreated, envelope{"image": rsp}, headers)
+}
+
+// generateThumbnail generates the thumbnail of an image, stores it along with the image and records its key.
+func (app *application) generateThumbnail(movieImage db.MovieImage, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), thumbnailTimeout)
+	defer cancel()
+
+	thumbnail, err := newThumbnail(bytes.NewReader(data), thumbnailDimension)
+	if err != nil {
+		return err
+	}
+
+	key := movieImage.BlobKey + ".thumbnail.jpg"
+
+	err = app.blobs.Put(ctx, key, bytes.NewReader(thumbnail), "image/jpeg")
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := app.store.SetMovieImageThumbnail(ctx, db.SetMovieImageThumbnailParams{
+		ID:           movieImage.ID,
+		ThumbnailKey: pgtype.Text{String: key, Valid: true},
+	})
+	if err != nil {
+		// The key may or may not have been recorded, so the thumbnail is kept.
+		return err
+	}
+
+	// The image, or its movie, has been deleted while the thumbnail was generated,
+	// so nobody else knows about the thumbnail anymore.
+	if rowsAffected == 0 {
+		app.deleteBlobs(key)
+	}
+
+	return nil
+}
+
+// deleteBlobs deletes files from the blob store in the background, logging the failures.
+func (app *application) deleteBlobs(keys ...string) {
+	app.background(func() {
+		for _, key := range keys {
+			err := app.blobs.Delete(context.Background(), key)
+			if err != nil {
+				app.logger.Error("failed to delete blob", "key", key, "error", err.Error())
+			}
+		}
+	})
+}
+
+// listMovieImagesHandler show the metadata of the images of a specific movie.
+func (app *application) listMovieImagesHandler(ctx *gin.Context) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	// Make sure the movie exists, so that we don't return an empty list for a movie that doesn't.
+	_, err = app.store.GetMovie(ctx, movieID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	movieImages, err := app.store.ListMovieImages(ctx, movieID)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	images := make([]movieImageResponse, 0, len(movieImages))
+	for _, movieImage := range movieImages {
+		images = append(images, newMovieImageResponse(movieImage))
+	}
+
+	app.writeJSON(ctx, http.StatusOK, envelope{"images": images}, nil)
+}
+
+// getMovieImage retrieve the image identified by the "id" and "image_id" URL parameters.
+// If it doesn't exist, an error response is sent and false is returned.
+func (app *application) getMovieImage(ctx *gin.Context) (db.MovieImage, bool) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return db.MovieImage{}, false
+	}
+
+	imageID, err := app.readNamedIDParam(ctx, "image_id")
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return db.MovieImage{}, false
+	}
+
+	movieImage, err := app.store.GetMovieImage(ctx, db.GetMovieImageParams{
+		ID:      imageID,
+		MovieID: movieID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return db.MovieImage{}, false
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return db.MovieImage{}, false
+	}
+
+	return movieImage, true
+}
+
+// serveMovieImageHandler download the file of an image of a specific movie, or its thumbnail with variant=thumbnail.
+// If the blob store can serve the file itself, the client is redirected to it instead.
+func (app *application) serveMovieImageHandler(ctx *gin.Context) {
+	movieImage, ok := app.getMovieImage(ctx)
+	if !ok {
+		return
+	}
+
+	key, contentType := movieImage.BlobKey, movieImage.ContentType
+	switch ctx.Query("variant") {
+	case "", "original":
+	case "thumbnail":
+		// The thumbnail may not have been generated yet.
+		if !movieImage.ThumbnailKey.Valid {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		key, contentType = movieImage.ThumbnailKey.String, "image/jpeg"
+	default:
+		violations := validator.New()
+		violations.AddError("variant", "must be either original or thumbnail")
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	url, err := app.blobs.URL(ctx, key)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	if url != "" {
+		ctx.Redirect(http.StatusFound, url)
+		return
+	}
+
+	file, err := app.blobs.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+	defer file.Close()
+
+	// The files are never modified, since a new image gets a new key.
+	headers := map[string]string{"Cache-Control": "private, max-age=86400"}
+	ctx.DataFromReader(http.StatusOK, -1, contentType, file, headers)
+}
+
+// deleteMovieImageHandler delete an image of a specific movie, along with its files.
+func (app *application) deleteMovieImageHandler(ctx *gin.Context) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	imageID, err := app.readNamedIDParam(ctx, "image_id")
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	movieImage, err := app.store.DeleteMovieImage(ctx, db.DeleteMovieImageParams{
+		ID:      imageID,
+		MovieID: movieID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	keys := []string{movieImage.BlobKey}
+	if movieImage.ThumbnailKey.Valid {
+		keys = append(keys, movieImage.ThumbnailKey.String)
+	}
+	app.deleteBlobs(keys...)
+
+	rsp := envelope{"message": "image successfully deleted!"}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katatrina/greenlight/internal/db"
+	"github.com/katatrina/greenlight/internal/validator"
+)
+
+const (
+	csvContentType    = "text/csv"
+	ndjsonContentType = "application/x-ndjson"
+
+	// The imports are much larger than the other request bodies.
+	maxImportBytes = 10 * 1_048_576
+
+	importBatchSize = 500
+)
+
+var (
+	errImportEmpty = errors.New("body must contain at least one movie")
+)
+
+type importMoviesRequest struct {
+	DryRun bool `form:"dry_run"`
+	Atomic bool `form:"atomic"`
+}
+
+// importRow is a movie read from a line of the imported file.
+// If the line couldn't be parsed, err is set instead.
+type importRow struct {
+	line  int
+	movie createMovieRequest
+	err   error
+}
+
+type importRowError struct {
+	Line       int                  `json:"line"`
+	Violations validator.Violations `json:"violations"`
+}
+
+// importBatchFailure is the batch of valid rows which couldn't be created.
+// Line is the line of the movie which couldn't be created, unless the batch failed as a whole.
+type importBatchFailure struct {
+	FirstLine int    `json:"first_line"`
+	LastLine  int    `json:"last_line"`
+	Line      int    `json:"line,omitempty"`
+	Error     string `json:"error"`
+}
+
+type importMoviesReport struct {
+	DryRun     bool                `json:"dry_run"`
+	Atomic     bool                `json:"atomic"`
+	TotalRows  int                 `json:"total_rows"`
+	ValidRows  int                 `json:"valid_rows"`
+	CreatedIDs []int64             `json:"created_ids"`
+	Errors     []importRowError    `json:"errors"`
+	Failure    *importBatchFailure `json:"failure,omitempty"`
+}
+
+// newImportBatchFailure describes the failed batch of an import with the lines of its rows,
+// given the lines of the valid rows.
+func newImportBatchFailure(err *db.ImportBatchError, lines []int) importBatchFailure {
+	failure := importBatchFailure{
+		FirstLine: lines[err.Start],
+		LastLine:  lines[err.End-1],
+		Error:     "the movies of this batch violate a constraint of the database",
+	}
+
+	if err.Movie >= 0 {
+		failure.Line = lines[err.Movie]
+		failure.Error = "the movie violates a constraint of the database"
+	}
+
+	return failure
+}
+
+// validateImportRow validates a row with the same rules as a single movie,
+// or reports the error of the row if it couldn't be parsed.
+func validateImportRow(row *importRow) validator.Violations {
+	if row.err != nil {
+		violations := validator.New()
+		violations.AddError("row", row.err.Error())
+
+		return violations
+	}
+
+	return validateCreateMovieRequest(&row.movie)
+}
+
+// importMoviesHandler create movies in bulk from a CSV or NDJSON body.
+//
+// The CSV must have a header line with the title, publish_year, runtime and genres columns,
+// where the runtime is a number of minutes and the genres are separated by "|".
+// Each NDJSON line is a movie, in the same format as for createMovieHandler.
+//
+// The valid movies are created even if some are not, unless atomic is set.
+// With dry_run, the movies are only validated.
+func (app *application) importMoviesHandler(ctx *gin.Context) {
+	var req importMoviesRequest
+
+	// Parse query parameters
+	err := app.readQueryParams(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes)
+
+	// Parse the request body according to its content type.
+	var rows []importRow
+	switch ctx.ContentType() {
+	case csvContentType:
+		rows, err = readCSVMovies(ctx.Request.Body)
+	case ndjsonContentType:
+		rows, err = readNDJSONMovies(ctx.Request.Body)
+	default:
+		app.unsupportedMediaTypeResponse(ctx, csvContentType, ndjsonContentType)
+		return
+	}
+	if err == nil && len(rows) == 0 {
+		err = errImportEmpty
+	}
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			err = fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		}
+
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	report := importMoviesReport{
+		DryRun:     req.DryRun,
+		Atomic:     req.Atomic,
+		TotalRows:  len(rows),
+		CreatedIDs: []int64{},
+		Errors:     []importRowError{},
+	}
+
+	resolver, err := app.genreResolver(ctx)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	// Validate every row with the same rules as a single movie.
+	// The lines of the valid rows are kept to report the one which couldn't be created, if any.
+	movies := make([]db.CreateMoviesParams, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	for _, row := range rows {
+		violations := validateImportRow(&row)
+
+		// The genres must be in the catalogue.
+		if violations.Empty() {
+			genres, err := resolver.resolve(row.movie.Genres)
+			if err != nil {
+				violations.AddError("genres", err.Error())
+			}
+			row.movie.Genres = genres
+		}
+
+		if !violations.Empty() {
+			report.Errors = append(report.Errors, importRowError{Line: row.line, Violations: violations})
+			continue
+		}
+
+		movies = append(movies, db.CreateMoviesParams{
+			Title:       row.movie.Title,
+			PublishYear: row.movie.PublishYear,
+			Runtime:     row.movie.Runtime,
+			Genres:      row.movie.Genres,
+		})
+		lines = append(lines, row.line)
+	}
+	report.ValidRows = len(movies)
+
+	if req.DryRun {
+		app.writeJSON(ctx, http.StatusOK, envelope{"report": report}, nil)
+		return
+	}
+
+	// Nothing is written if an atomic import has an invalid row, or if there is no valid row at all.
+	if (req.Atomic && len(report.Errors) > 0) || len(movies) == 0 {
+		app.writeJSON(ctx, http.StatusUnprocessableEntity, envelope{"report": report}, nil)
+		return
+	}
+
+	ids, err := app.store.ImportMoviesTx(ctx, db.ImportMoviesTxParams{
+		Movies:    movies,
+		BatchSize: importBatchSize,
+		Atomic:    req.Atomic,
+		UserID:    app.contextGetUser(ctx).ID,
+	})
+	if ids != nil {
+		report.CreatedIDs = ids
+	}
+	if err != nil {
+		var batchErr *db.ImportBatchError
+		if !errors.As(err, &batchErr) {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+
+		// Some batches of a non-atomic import may have been committed before the error, so the report
+		// tells which movies have been created, and which batch failed.
+		failure := newImportBatchFailure(batchErr, lines)
+		status := http.StatusUnprocessableEntity
+		if !db.IsDataError(err) {
+			app.logError(ctx, err)
+			failure.Error = "the server encountered a problem and could not create the movies of this batch"
+			status = http.StatusInternalServerError
+		}
+
+		report.Failure = &failure
+		app.writeJSON(ctx, status, envelope{"report": report}, nil)
+		return
+	}
+
+	app.writeJSON(ctx, http.StatusCreated, envelope{"report": report}, nil)
+}
+
+// readCSVMovies reads the movies of a CSV file.
+// It only returns an error if the file itself is malformed; the errors of a single row are kept in that row.
+func readCSVMovies(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errImportEmpty
+		}
+
+		return nil, fmt.Errorf("body contains badly-formed CSV: %w", err)
+	}
+
+	// The columns can be in any order.
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+
+	for _, name := range []string{"title", "publish_year", "runtime", "genres"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header must contain the %q column", name)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		// A record with a wrong number of fields is only an error of that row.
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("body contains badly-formed CSV: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := importRow{line: line}
+
+		if err != nil {
+			row.err = errors.New("must have the same number of fields as the header")
+			rows = append(rows, row)
+			continue
+		}
+
+		row.movie.Title = record[columns["title"]]
+
+		publishYear, err := strconv.ParseInt(record[columns["publish_year"]], 10, 32)
+		if err != nil {
+			row.err = errors.New("publish_year must be an integer")
+			rows = append(rows, row)
+			continue
+		}
+		row.movie.PublishYear = int32(publishYear)
+
+		// We also accept the "<runtime> mins" format of the JSON responses.
+		runtime, err := strconv.ParseInt(strings.TrimSuffix(record[columns["runtime"]], " mins"), 10, 32)
+		if err != nil {
+			row.err = errors.New("runtime must be a number of minutes")
+			rows = append(rows, row)
+			continue
+		}
+		row.movie.Runtime = db.Runtime(runtime)
+
+		if genres := record[columns["genres"]]; genres != "" {
+			row.movie.Genres = strings.Split(genres, "|")
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readNDJSONMovies reads the movies of a newline-delimited JSON file, skipping the blank lines.
+// It only returns an error if the file can't be read; the errors of a single line are kept in that row.
+func readNDJSONMovies(r io.Reader) ([]importRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1_048_576)
+
+	var rows []importRow
+	for line := 1; scanner.Scan(); line++ {
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		row := importRow{line: line}
+		if err := json.Unmarshal([]byte(data), &row.movie); err != nil {
+			if errors.Is(err, db.ErrInvalidRuntimeFormat) {
+				row.err = errors.New(`runtime must be in the "<runtime> mins" format`)
+			} else {
+				row.err = errors.New("must be a valid JSON movie")
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, errors.New("body must not contain lines larger than 1048576 bytes")
+		}
+
+		return nil, err
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/katatrina/greenlight/internal/db"
+)
+
+func TestReadCSVMovies(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []createMovieRequest
+		errRows map[int]string // The error of the rows by line.
+		wantErr bool
+	}{
+		{
+			name: "valid rows",
+			body: "title,publish_year,runtime,genres\nAlien,1979,117,horror|sci-fi\nHeat,1995,170 mins,crime\n",
+			want: []createMovieRequest{
+				{Title: "Alien", PublishYear: 1979, Runtime: 117, Genres: []string{"horror", "sci-fi"}},
+				{Title: "Heat", PublishYear: 1995, Runtime: 170, Genres: []string{"crime"}},
+			},
+		},
+		{
+			name: "columns in any order",
+			body: "Genres, Runtime, Title, Publish_Year\ndrama,142,Casablanca,1942\n",
+			want: []createMovieRequest{
+				{Title: "Casablanca", PublishYear: 1942, Runtime: 142, Genres: []string{"drama"}},
+			},
+		},
+		{
+			name: "errors of single rows",
+			body: "title,publish_year,runtime,genres\nAlien,next year,117,horror\nHeat,1995,long,crime\nJaws,1975\nUp,2009,96,\n",
+			want: []createMovieRequest{{}, {}, {}, {Title: "Up", PublishYear: 2009, Runtime: 96}},
+			errRows: map[int]string{
+				2: "publish_year must be an integer",
+				3: "runtime must be a number of minutes",
+				4: "must have the same number of fields as the header",
+			},
+		},
+		{
+			name:    "missing column",
+			body:    "title,publish_year,genres\nAlien,1979,horror\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty body",
+			body:    "",
+			wantErr: true,
+		},
+		{
+			name:    "badly-formed CSV",
+			body:    "title,publish_year,runtime,genres\n\"Alien,1979,117,horror\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := readCSVMovies(strings.NewReader(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readCSVMovies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(rows) != len(tt.want) {
+				t.Fatalf("readCSVMovies() returned %d rows, want %d", len(rows), len(tt.want))
+			}
+
+			for i, row := range rows {
+				if wantErr, ok := tt.errRows[row.line]; ok {
+					if row.err == nil || row.err.Error() != wantErr {
+						t.Errorf("line %d: error = %v, want %q", row.line, row.err, wantErr)
+					}
+					continue
+				}
+
+				if row.err != nil {
+					t.Errorf("line %d: unexpected error %v", row.line, row.err)
+				}
+
+				if !reflect.DeepEqual(row.movie, tt.want[i]) {
+					t.Errorf("line %d: movie = %+v, want %+v", row.line, row.movie, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadNDJSONMovies(t *testing.T) {
+	body := strings.Join([]string{
+		`{"title":"Alien","publish_year":1979,"runtime":"117 mins","genres":["horror"]}`,
+		``,
+		`{"title":"Heat","runtime":170}`,
+		`not json`,
+	}, "\n")
+
+	tests := []struct {
+		line    int
+		title   string
+		wantErr string
+	}{
+		{line: 1, title: "Alien"},
+		{line: 3, wantErr: `runtime must be in the "<runtime> mins" format`},
+		{line: 4, wantErr: "must be a valid JSON movie"},
+	}
+
+	rows, err := readNDJSONMovies(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("readNDJSONMovies() error = %v", err)
+	}
+
+	if len(rows) != len(tests) {
+		t.Fatalf("readNDJSONMovies() returned %d rows, want %d", len(rows), len(tests))
+	}
+
+	for i, tt := range tests {
+		row := rows[i]
+		if row.line != tt.line {
+			t.Errorf("row %d: line = %d, want %d", i, row.line, tt.line)
+		}
+
+		if tt.wantErr != "" {
+			if row.err == nil || row.err.Error() != tt.wantErr {
+				t.Errorf("line %d: error = %v, want %q", row.line, row.err, tt.wantErr)
+			}
+		} else if row.err != nil || row.movie.Title != tt.title {
+			t.Errorf("line %d: movie = %+v, error = %v, want the title %q", row.line, row.movie, row.err, tt.title)
+		}
+	}
+}
+
+func TestValidateImportRow(t *testing.T) {
+	valid := createMovieRequest{Title: "Alien", PublishYear: 1979, Runtime: 117, Genres: []string{"horror"}}
+
+	tests := []struct {
+		name       string
+		row        importRow
+		wantFields []string
+	}{
+		{"valid row", importRow{movie: valid}, nil},
+		{"parse error", importRow{err: errors.New("publish_year must be an integer")}, []string{"row"}},
+		{"invalid title", importRow{movie: createMovieRequest{Title: "A", PublishYear: 1979, Runtime: 117, Genres: []string{"horror"}}}, []string{"title"}},
+		{"invalid year and runtime", importRow{movie: createMovieRequest{Title: "Alien", PublishYear: 1800, Runtime: 0, Genres: []string{"horror"}}}, []string{"publish_year", "runtime"}},
+		{"duplicate genres", importRow{movie: createMovieRequest{Title: "Alien", PublishYear: 1979, Runtime: 117, Genres: []string{"Sci-Fi", "sci fi"}}}, []string{"genres"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := validateImportRow(&tt.row)
+
+			if len(violations) != len(tt.wantFields) {
+				t.Fatalf("validateImportRow() = %v, want violations of %v", violations, tt.wantFields)
+			}
+
+			for _, field := range tt.wantFields {
+				if _, ok := violations[field]; !ok {
+					t.Errorf("validateImportRow() = %v, want a violation of %q", violations, field)
+				}
+			}
+		})
+	}
+}
+
+func TestNewImportBatchFailure(t *testing.T) {
+	// The lines of the valid rows; the missing lines were invalid rows.
+	lines := []int{2, 3, 5, 6, 9, 10}
+
+	tests := []struct {
+		name string
+		err  *db.ImportBatchError
+		want importBatchFailure
+	}{
+		{
+			name: "movie of the first batch",
+			err:  &db.ImportBatchError{Start: 0, End: 3, Movie: 1},
+			want: importBatchFailure{FirstLine: 2, LastLine: 5, Line: 3, Error: "the movie violates a constraint of the database"},
+		},
+		{
+			name: "last batch",
+			err:  &db.ImportBatchError{Start: 3, End: 6, Movie: 5},
+			want: importBatchFailure{FirstLine: 6, LastLine: 10, Line: 10, Error: "the movie violates a constraint of the database"},
+		},
+		{
+			name: "whole batch",
+			err:  &db.ImportBatchError{Start: 3, End: 6, Movie: -1},
+			want: importBatchFailure{FirstLine: 6, LastLine: 10, Error: "the movies of this batch violate a constraint of the database"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newImportBatchFailure(tt.err, lines); got != tt.want {
+				t.Errorf("newImportBatchFailure() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
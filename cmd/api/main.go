@@ -2,62 +2,87 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
 	"log/slog"
-	"net/http"
 	"os"
-	"time"
+	"sync"
+	"sync/atomic"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/katatrina/greenlight/internal/blob"
 	"github.com/katatrina/greenlight/internal/db"
 	"github.com/katatrina/greenlight/internal/mailer"
 )
 
-// Application version number
-const version = "1.0.0"
-
-// Configuration settings for our application.
-type config struct {
-	port int
-	env  string
-	db   struct {
-		dsn string
-	}
-	smtp struct {
-		username string
-		password string
-	}
-}
-
 // application hold dependencies for our HTTP handlers, helpers, and middlewares.
 type application struct {
-	config config
-	logger *slog.Logger
-	store  db.Store
-	mailer mailer.EmailSender
+	config  config
+	logger  *slog.Logger
+	store   db.Store
+	mailer  mailer.EmailSender
+	blobs   blob.BlobStore
+	metrics *metrics
+
+	// wg tracks the goroutines started by background(), so that we can wait for them on shutdown.
+	wg sync.WaitGroup
+	// backgroundTasks is the number of background tasks which are still running,
+	// and finishedTasks the number of background tasks which have finished since the start.
+	backgroundTasks atomic.Int64
+	finishedTasks   atomic.Int64
+
+	// genres caches the genres of the catalogue.
+	genres genreCache
+
+	// ipLimiters and userLimiters hold the rate limiters of the clients, by IP address and by user ID.
+	ipLimiters   *clientLimiters
+	userLimiters *clientLimiters
 }
 
 func main() {
-	var cfg config
+	cfg, err := loadConfig(os.Args[1:], os.Stdout)
+	if err != nil {
+		if errors.Is(err, errExitEarly) || errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
 
-	// Read the value of the port and env command-line flags into the config struct.
-	flag.IntVar(&cfg.port, "port", 4000, "API server port")
-	flag.StringVar(&cfg.env, "env", "development", "Environment (development|staging|production)")
-	flag.StringVar(&cfg.db.dsn, "db-dsn", os.Getenv("GREENLIGHT_DB_DSN"), "PostgreSQL DSN")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	flag.StringVar(&cfg.smtp.username, "mailtrap-smtp-username", os.Getenv("MAILTRAP_SMTP_USERNAME"), "Mailtrap SMTP username")
-	flag.StringVar(&cfg.smtp.password, "mailtrap-smtp-password", os.Getenv("MAILTRAP_SMTP_PASSWORD"), "Mailtrap SMTP password")
+	// Initialize a new structured logger which writes log entries to the standard out stream.
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.logLevel}))
 
-	flag.Parse()
+	// The program only exits once run() has returned, so that its deferred calls have closed the dependencies.
+	err = run(cfg, logger)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+}
 
-	// Initialize a new structured logger which writes log entries to the standard out stream.
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+// run opens the dependencies of the application, then serves it until it's shut down.
+func run(cfg config, logger *slog.Logger) error {
+	// Without a configured secret, the cursors can't be shared between instances nor survive a restart.
+	if cfg.cursor.secret == "" {
+		logger.Warn("no cursor secret configured, using a random one")
+
+		secret := make([]byte, 32)
+
+		_, err := rand.Read(secret)
+		if err != nil {
+			return err
+		}
+
+		cfg.cursor.secret = hex.EncodeToString(secret)
+	}
 
 	connPool, err := openDB(cfg)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	defer connPool.Close()
@@ -66,48 +91,64 @@ func main() {
 
 	store := db.NewStore(connPool)
 
-	mailer, err := mailer.NewMailtrapSender(cfg.smtp.username, cfg.smtp.password)
+	mailer, err := mailer.NewSMTPSender(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	app := application{
-		config: cfg,
-		logger: logger,
-		store:  store,
-		mailer: mailer,
+	blobs, err := openBlobStore(cfg)
+	if err != nil {
+		return err
 	}
 
-	// Declare a HTTP server which listens on the port provided in the config struct,
-	// uses the servemux we created above as the handler, has some sensible timeout
-	// settings and writes any log messages to the structured logger at Error level.
-	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.port),
-		Handler:      app.routes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	app := &application{
+		config:  cfg,
+		logger:  logger,
+		store:   store,
+		mailer:  mailer,
+		blobs:   blobs,
+		metrics: newMetrics(store),
+
+		ipLimiters:   newClientLimiters(),
+		userLimiters: newClientLimiters(),
 	}
 
-	// Start the HTTP server.
-	logger.Info("server is listening", "addr", srv.Addr, "env", cfg.env)
+	// Start the HTTP server. The connection pool is closed by the deferred call above,
+	// only after the server and the background tasks have been shut down.
+	return app.serve()
+}
 
-	err = srv.ListenAndServe()
-	logger.Error(err.Error())
-	os.Exit(1)
+// openBlobStore creates the store of the uploaded files for the configured backend.
+func openBlobStore(cfg config) (blob.BlobStore, error) {
+	switch cfg.blob.backend {
+	case "local":
+		return blob.NewLocalStore(cfg.blob.localDir)
+	default:
+		return nil, fmt.Errorf("unsupported blob backend %q", cfg.blob.backend)
+	}
 }
 
 // openDB creates a new connection pool to our PostgreSQL database.
 func openDB(cfg config) (*pgxpool.Pool, error) {
-	connPool, err := pgxpool.New(context.Background(), cfg.db.dsn)
+	poolConfig, err := pgxpool.ParseConfig(cfg.db.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the connection pool settings from the config struct.
+	poolConfig.MaxConns = int32(cfg.db.maxConns)
+	poolConfig.MinConns = int32(cfg.db.minConns)
+	poolConfig.MaxConnIdleTime = cfg.db.maxConnIdleTime
+	poolConfig.MaxConnLifetime = cfg.db.maxConnLifetime
+	poolConfig.ConnConfig.ConnectTimeout = cfg.db.connectTimeout
 
+	connPool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create a context with a 5-second timeout.
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Create a context with the connection timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.db.connectTimeout)
 	defer cancel()
 
 	// Ping the database to check if the connection is working.
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katatrina/greenlight/internal/db"
+)
+
+// publishedMetrics are the names of the expvar variables served by metricsHandler: the application metrics
+// and the memory statistics of the runtime. The "cmdline" variable published by the expvar package is left out,
+// since it holds the command-line flags, secrets included.
+var publishedMetrics = []string{
+	"total_requests_received",
+	"total_responses_sent",
+	"total_responses_sent_by_status",
+	"in_flight_requests",
+	"total_emails_sent",
+	"total_emails_failed",
+	"request_latency_seconds",
+	"database",
+	"memstats",
+}
+
+// latencyBuckets are the upper bounds (in seconds) of the request latency histogram buckets.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metrics holds the application metrics, which are published through the expvar package.
+type metrics struct {
+	totalRequests     *expvar.Int
+	totalResponses    *expvar.Int
+	responsesByStatus *expvar.Map
+	inFlightRequests  *expvar.Int
+	emailsSent        *expvar.Int
+	emailsFailed      *expvar.Int
+
+	// latencies maps "<method> <route>", or "unmatched" for the unknown routes, to the *histogram of that route.
+	mu        sync.Mutex
+	latencies *expvar.Map
+}
+
+// newMetrics creates and publishes the application metrics.
+//
+// It must be called only once, since expvar panics when a variable name is published twice.
+func newMetrics(store db.Store) *metrics {
+	m := &metrics{
+		totalRequests:     expvar.NewInt("total_requests_received"),
+		totalResponses:    expvar.NewInt("total_responses_sent"),
+		responsesByStatus: expvar.NewMap("total_responses_sent_by_status"),
+		inFlightRequests:  expvar.NewInt("in_flight_requests"),
+		emailsSent:        expvar.NewInt("total_emails_sent"),
+		emailsFailed:      expvar.NewInt("total_emails_failed"),
+		latencies:         expvar.NewMap("request_latency_seconds"),
+	}
+
+	// Publish the connection pool statistics, which are computed on each read.
+	expvar.Publish("database", expvar.Func(func() any {
+		stat := store.Stat()
+
+		return map[string]any{
+			"acquired_conns":             stat.AcquiredConns(),
+			"idle_conns":                 stat.IdleConns(),
+			"total_conns":                stat.TotalConns(),
+			"max_conns":                  stat.MaxConns(),
+			"empty_acquire_count":        stat.EmptyAcquireCount(),
+			"acquire_count":              stat.AcquireCount(),
+			"acquire_duration_seconds":   stat.AcquireDuration().Seconds(),
+			"canceled_acquire_count":     stat.CanceledAcquireCount(),
+			"constructing_conns":         stat.ConstructingConns(),
+			"new_conns_count":            stat.NewConnsCount(),
+			"max_lifetime_destroy_count": stat.MaxLifetimeDestroyCount(),
+			"max_idle_destroy_count":     stat.MaxIdleDestroyCount(),
+		}
+	}))
+
+	return m
+}
+
+// observeLatency records the duration of a request to the latency histogram of the given route.
+func (m *metrics) observeLatency(route string, duration time.Duration) {
+	m.mu.Lock()
+	h, ok := m.latencies.Get(route).(*histogram)
+	if !ok {
+		h = newHistogram(latencyBuckets)
+		m.latencies.Set(route, h)
+	}
+	m.mu.Unlock()
+
+	h.observe(duration.Seconds())
+}
+
+// histogram is a cumulative histogram, in the same shape as a Prometheus histogram.
+// It implements the expvar.Var interface.
+type histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []int64
+	count   int64
+	sum     float64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]int64, len(buckets)),
+	}
+}
+
+// observe adds a single observation to the histogram.
+func (h *histogram) observe(value float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upperBound := range h.buckets {
+		if value <= upperBound {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += value
+}
+
+// String returns the JSON representation of the histogram.
+func (h *histogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var b strings.Builder
+
+	b.WriteString(`{"buckets": {`)
+	for i, upperBound := range h.buckets {
+		fmt.Fprintf(&b, "%q: %d, ", strconv.FormatFloat(upperBound, 'f', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(&b, `"+Inf": %d}, "count": %d, "sum": %g}`, h.count, h.count, h.sum)
+
+	return b.String()
+}
+
+// metricsHandler writes the published metrics as a JSON object, in the same format as expvar.Handler().
+func (app *application) metricsHandler(ctx *gin.Context) {
+	ctx.Header("Content-Type", "application/json; charset=utf-8")
+	ctx.Status(http.StatusOK)
+
+	var b strings.Builder
+
+	b.WriteString("{\n")
+	written := 0
+	for _, name := range publishedMetrics {
+		v := expvar.Get(name)
+		if v == nil {
+			continue
+		}
+
+		if written > 0 {
+			b.WriteString(",\n")
+		}
+		fmt.Fprintf(&b, "%q: %s", name, v.String())
+		written++
+	}
+	b.WriteString("\n}\n")
+
+	ctx.Writer.WriteString(b.String())
+}
+
+// recordMetrics middleware collects the request and response metrics.
+func (app *application) recordMetrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		app.metrics.totalRequests.Add(1)
+		app.metrics.inFlightRequests.Add(1)
+
+		// The metrics are recorded even if a later handler panics, so that the in-flight requests don't leak.
+		defer func() {
+			app.metrics.inFlightRequests.Add(-1)
+			app.metrics.totalResponses.Add(1)
+			app.metrics.responsesByStatus.Add(strconv.Itoa(ctx.Writer.Status()), 1)
+
+			// Use the route pattern rather than the actual URL path, so that the number of histograms stays bounded.
+			// The unmatched requests share a single histogram, since their method is arbitrary as well.
+			route := "unmatched"
+			if fullPath := ctx.FullPath(); fullPath != "" {
+				route = ctx.Request.Method + " " + fullPath
+			}
+
+			app.metrics.observeLatency(route, time.Since(start))
+		}()
+
+		ctx.Next()
+	}
+}
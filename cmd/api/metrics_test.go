@@ -0,0 +1,78 @@
+package main
+
+import (
+	"expvar"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestMetricsApp returns an application with unpublished metrics, since expvar only publishes a name once.
+func newTestMetricsApp() *application {
+	return &application{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		metrics: &metrics{
+			totalRequests:     new(expvar.Int),
+			totalResponses:    new(expvar.Int),
+			responsesByStatus: new(expvar.Map).Init(),
+			inFlightRequests:  new(expvar.Int),
+			emailsSent:        new(expvar.Int),
+			emailsFailed:      new(expvar.Int),
+			latencies:         new(expvar.Map).Init(),
+		},
+	}
+}
+
+func TestRecordMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus string
+		wantRoute  string
+	}{
+		{"matched route", http.MethodGet, "/v1/movies/1", "200", "GET /v1/movies/:id"},
+		{"panic", http.MethodGet, "/v1/panic", "500", "GET /v1/panic"},
+		{"unknown path", http.MethodGet, "/v1/unknown", "404", "unmatched"},
+		{"arbitrary method", "FOO", "/v1/unknown", "404", "unmatched"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestMetricsApp()
+
+			router := gin.New()
+			router.NoRoute(app.notFoundResponse)
+			router.Use(app.recordMetrics())
+			router.Use(app.recoverPanic())
+			router.GET("/v1/movies/:id", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+			router.GET("/v1/panic", func(ctx *gin.Context) { panic("boom") })
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			if got := app.metrics.inFlightRequests.Value(); got != 0 {
+				t.Errorf("in_flight_requests = %d, want 0", got)
+			}
+
+			if got := app.metrics.totalResponses.Value(); got != 1 {
+				t.Errorf("total_responses_sent = %d, want 1", got)
+			}
+
+			if got := app.metrics.responsesByStatus.Get(tt.wantStatus); got == nil || got.String() != "1" {
+				t.Errorf("total_responses_sent_by_status[%s] = %v, want 1", tt.wantStatus, got)
+			}
+
+			var routes []string
+			app.metrics.latencies.Do(func(kv expvar.KeyValue) { routes = append(routes, kv.Key) })
+			if len(routes) != 1 || routes[0] != tt.wantRoute {
+				t.Errorf("request_latency_seconds routes = %v, want [%s]", routes, tt.wantRoute)
+			}
+		})
+	}
+}
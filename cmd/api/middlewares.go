@@ -1,23 +1,123 @@
 package main
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"runtime/debug"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/katatrina/greenlight/internal/db"
 	"github.com/katatrina/greenlight/internal/validator"
+	"golang.org/x/time/rate"
 )
 
+var (
+	isValidRequestID = regexp.MustCompile(`^[a-zA-Z0-9._:-]{1,128}$`).MatchString
+)
+
+// recoverPanic middleware recovers from any panic in the rest of the chain, logs it along with the stack trace,
+// and sends a 500 Internal Server Error response to the client.
+func (app *application) recoverPanic() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		defer func() {
+			panicVal := recover()
+			if panicVal == nil {
+				return
+			}
+
+			err := fmt.Errorf("panic: %v\n%s", panicVal, debug.Stack())
+
+			// If the response has already been started, we can't send an error response anymore,
+			// so we only log the error.
+			if ctx.Writer.Written() {
+				app.logError(ctx, err)
+				ctx.Abort()
+				return
+			}
+
+			// Make Go's HTTP server automatically close the current connection
+			// after the response has been sent.
+			ctx.Header("Connection", "close")
+
+			app.serverErrorResponse(ctx, err)
+			ctx.Abort()
+		}()
+
+		ctx.Next()
+	}
+}
+
+// requestID middleware assigns an ID to each request, so that all the log entries of a request can be linked together.
+//
+// The ID provided by the client (or a proxy) in the X-Request-ID header is used if it is sensible,
+// otherwise a new random ID is generated. The ID is returned to the client in the X-Request-ID response header.
+func (app *application) requestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader("X-Request-ID")
+
+		if !isValidRequestID(requestID) {
+			randomBytes := make([]byte, 16)
+
+			_, err := rand.Read(randomBytes)
+			if err != nil {
+				app.serverErrorResponse(ctx, err)
+				ctx.Abort()
+				return
+			}
+
+			requestID = hex.EncodeToString(randomBytes)
+		}
+
+		app.contextSetRequestID(ctx, requestID)
+		ctx.Header("X-Request-ID", requestID)
+
+		ctx.Next()
+	}
+}
+
+// logRequest middleware writes a structured access log entry for each request.
+func (app *application) logRequest() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		// The user is not set in the request context if the request has been rejected
+		// before (or by) the authenticate middleware, so we can't use contextGetUser here.
+		var userID int64
+		if user, ok := ctx.Value(userContextKey).(*db.User); ok {
+			userID = user.ID
+		}
+
+		app.logger.Info("request completed",
+			"method", ctx.Request.Method,
+			"path", ctx.Request.URL.Path,
+			"status", ctx.Writer.Status(),
+			"bytes", max(ctx.Writer.Size(), 0),
+			"duration", time.Since(start),
+			"user_id", userID,
+			"request_id", app.contextGetRequestID(ctx),
+		)
+	}
+}
+
 // authenticate middleware indicates which user a request is coming from, either an authenticated user or an anonymous user.
 func (app *application) authenticate() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// Add the "Vary: Authorization" header to the response. This indicates to any
 		// caches that the response may vary based on the value of the Authorization
-		// header in the request.
-		ctx.Header("Vary", "Authorization")
+		// header in the request. We use Add() rather than Set() to keep the "Vary: Origin" header.
+		ctx.Writer.Header().Add("Vary", "Authorization")
 
 		// Retrieve the value of the Authorization header from the request. This will
 		// return an empty string "" if there is no such header found.
@@ -81,6 +181,134 @@ func (app *application) authenticate() gin.HandlerFunc {
 	}
 }
 
+// enableCORS middleware allows cross-origin requests from the trusted origins,
+// and answers the CORS preflight requests.
+func (app *application) enableCORS() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// The response will be different depending on the origin and the preflight
+		// request method, so let any caches know about it.
+		ctx.Writer.Header().Add("Vary", "Origin")
+		ctx.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+
+		origin := ctx.GetHeader("Origin")
+
+		// A preflight request is an OPTIONS request with the Access-Control-Request-Method header.
+		preflight := ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+
+		// Only echo the origin back if it is in the trusted origins list.
+		if origin != "" && slices.Contains(app.config.cors.trustedOrigins, origin) {
+			ctx.Header("Access-Control-Allow-Origin", origin)
+			// Let the clients read the ETag, in order to send it back in the conditional requests.
+			ctx.Header("Access-Control-Expose-Headers", "ETag")
+
+			if preflight {
+				ctx.Header("Access-Control-Allow-Methods", "OPTIONS, GET, POST, PUT, PATCH, DELETE")
+				ctx.Header("Access-Control-Allow-Headers", "Authorization, Content-Type, If-Match, If-None-Match")
+			}
+		}
+
+		// Every preflight request is answered and stops the chain here, otherwise it would end up
+		// in the NoMethod handler. Without the CORS headers, the browser rejects the untrusted origins.
+		if preflight {
+			ctx.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// rateLimitByIP middleware limits the request rate of each IP address using a token bucket.
+//
+// It must be used before the authenticate middleware, so that the requests with invalid credentials
+// are limited before reaching the database. The anonymous requests take a token from the bucket of
+// their IP address straight away. The requests with credentials are only rejected if the bucket is
+// empty, and they take a token from it only if their authentication fails, since the authenticated
+// users have their own buckets (see rateLimitByUser).
+func (app *application) rateLimitByIP() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !app.config.limiter.enabled {
+			ctx.Next()
+			return
+		}
+
+		key := ctx.ClientIP()
+		limit := rate.Limit(app.config.limiter.rps)
+		burst := app.config.limiter.burst
+		now := time.Now()
+
+		if ctx.GetHeader("Authorization") == "" {
+			allowed, tokens := app.ipLimiters.allow(key, limit, burst, now)
+			if !app.checkRateLimit(ctx, allowed, tokens, limit, burst) {
+				return
+			}
+
+			ctx.Next()
+			return
+		}
+
+		tokens := app.ipLimiters.tokens(key, limit, burst, now)
+		if tokens < 1 {
+			app.checkRateLimit(ctx, false, tokens, limit, burst)
+			return
+		}
+
+		ctx.Next()
+
+		// The user is only set in the request context once the authentication has succeeded.
+		if _, ok := ctx.Value(userContextKey).(*db.User); !ok {
+			app.ipLimiters.charge(key, limit, burst, time.Now())
+		}
+	}
+}
+
+// rateLimitByUser middleware limits the request rate of each authenticated user using a token bucket.
+// It must be used after the authenticate middleware, the anonymous requests being limited by rateLimitByIP.
+func (app *application) rateLimitByUser() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := app.contextGetUser(ctx)
+		if !app.config.limiter.enabled || user.IsAnonymous() {
+			ctx.Next()
+			return
+		}
+
+		limit := rate.Limit(app.config.limiter.userRPS)
+		burst := app.config.limiter.userBurst
+
+		allowed, tokens := app.userLimiters.allow(strconv.FormatInt(user.ID, 10), limit, burst, time.Now())
+		if !app.checkRateLimit(ctx, allowed, tokens, limit, burst) {
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// checkRateLimit lets the client know about its quota, and how many seconds until its bucket is full again.
+// If the request isn't allowed, a 429 response is sent, the chain is aborted and false is returned.
+func (app *application) checkRateLimit(ctx *gin.Context, allowed bool, tokens float64, limit rate.Limit, burst int) bool {
+	ctx.Header("RateLimit-Limit", strconv.Itoa(burst))
+	ctx.Header("RateLimit-Remaining", strconv.Itoa(max(int(tokens), 0)))
+	ctx.Header("RateLimit-Reset", strconv.Itoa(int(math.Ceil(durationFromTokens(limit, float64(burst)-tokens).Seconds()))))
+
+	if !allowed {
+		app.rateLimitExceededResponse(ctx, durationFromTokens(limit, 1-tokens))
+		ctx.Abort()
+		return false
+	}
+
+	return true
+}
+
+// durationFromTokens returns the time it takes for the limiter to accumulate the given number of tokens.
+func durationFromTokens(limit rate.Limit, tokens float64) time.Duration {
+	if limit <= 0 || tokens <= 0 {
+		return 0
+	}
+
+	return time.Duration(tokens / float64(limit) * float64(time.Second))
+}
+
 // requireActivatedUser middleware restricts access to activated user accounts.
 func (app *application) requireActivatedUser() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -61,12 +63,22 @@ func (app *application) createMovieHandler(ctx *gin.Context) {
 		return
 	}
 
-	// Create a new movie record in the database.
-	movie, err := app.store.CreateMovie(ctx, db.CreateMovieParams{
-		Title:       req.Title,
-		PublishYear: req.PublishYear,
-		Runtime:     req.Runtime,
-		Genres:      req.Genres,
+	// The genres must be in the catalogue.
+	var ok bool
+	req.Genres, ok = app.resolveGenres(ctx, req.Genres)
+	if !ok {
+		return
+	}
+
+	// Create a new movie record in the database, along with its first revision.
+	movie, err := app.store.CreateMovieTx(ctx, db.CreateMovieTxParams{
+		CreateMovieParams: db.CreateMovieParams{
+			Title:       req.Title,
+			PublishYear: req.PublishYear,
+			Runtime:     req.Runtime,
+			Genres:      req.Genres,
+		},
+		UserID: app.contextGetUser(ctx).ID,
 	})
 	if err != nil {
 		app.serverErrorResponse(ctx, err)
@@ -77,12 +89,94 @@ func (app *application) createMovieHandler(ctx *gin.Context) {
 	// where they can find the newly-created movie resource at.
 	headers := make(map[string]string)
 	headers["Location"] = "/v1/movies/" + strconv.FormatInt(movie.ID, 10)
+	headers["ETag"] = movieETag(movie)
 
 	rsp := envelope{"movie": movie}
 	app.writeJSON(ctx, http.StatusCreated, rsp, headers)
 }
 
+// movieFieldSafeList holds the permitted values of the fields query parameter of the movie responses.
+var movieFieldSafeList = []string{"id", "title", "runtime", "genres", "publish_year", "version", "created_at", "average_rating", "review_count"}
+
+// movieListFieldSafeList holds the permitted values of the fields query parameter of the movie lists,
+// whose movies also have the match_score of the title search.
+var movieListFieldSafeList = append(slices.Clone(movieFieldSafeList), "match_score")
+
+// movieIncludeSafeList holds the relations which can be embedded in the movie responses with the include query parameter.
+var movieIncludeSafeList = []string{"reviews_summary", "revision_count"}
+
+// The reviews summary of a movie embeds its latestReviewsPerMovie most recent reviews.
+const latestReviewsPerMovie = 3
+
+type reviewsSummary struct {
+	AverageRating float64     `json:"average_rating"`
+	ReviewCount   int32       `json:"review_count"`
+	LatestReviews []db.Review `json:"latest_reviews"`
+}
+
+// loadMovieRelations retrieves the relations of the movies listed in include, indexed by the movie IDs.
+// Each relation is retrieved for all the movies at once.
+func (app *application) loadMovieRelations(ctx context.Context, include []string, movies []db.Movie) (map[int64]map[string]any, error) {
+	relations := make(map[int64]map[string]any, len(movies))
+	if len(include) == 0 || len(movies) == 0 {
+		return relations, nil
+	}
+
+	movieIDs := make([]int64, 0, len(movies))
+	for _, movie := range movies {
+		movieIDs = append(movieIDs, movie.ID)
+		relations[movie.ID] = make(map[string]any, len(include))
+	}
+
+	if slices.Contains(include, "reviews_summary") {
+		reviews, err := app.store.ListLatestMovieReviews(ctx, db.ListLatestMovieReviewsParams{
+			MovieIDs: movieIDs,
+			PerMovie: latestReviewsPerMovie,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		latestReviews := make(map[int64][]db.Review)
+		for _, review := range reviews {
+			latestReviews[review.MovieID] = append(latestReviews[review.MovieID], review)
+		}
+
+		for _, movie := range movies {
+			summary := reviewsSummary{
+				AverageRating: movie.AverageRating,
+				ReviewCount:   movie.ReviewCount,
+				LatestReviews: latestReviews[movie.ID],
+			}
+			if summary.LatestReviews == nil {
+				summary.LatestReviews = []db.Review{}
+			}
+
+			relations[movie.ID]["reviews_summary"] = summary
+		}
+	}
+
+	if slices.Contains(include, "revision_count") {
+		rows, err := app.store.CountMovieRevisions(ctx, movieIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, movieID := range movieIDs {
+			relations[movieID]["revision_count"] = int64(0)
+		}
+
+		for _, row := range rows {
+			relations[row.MovieID]["revision_count"] = row.RevisionCount
+		}
+	}
+
+	return relations, nil
+}
+
 // showMovieHandler show the details of a specific movie.
+//
+// The fields parameter trims the movie to some of its fields, and the include parameter embeds some of its relations.
 func (app *application) showMovieHandler(ctx *gin.Context) {
 	// Try to convert the id string to a base 10 integer (with a bit size of 64).
 	movieID, err := app.readIDParam(ctx)
@@ -91,6 +185,23 @@ func (app *application) showMovieHandler(ctx *gin.Context) {
 		return
 	}
 
+	var req shapeRequest
+
+	// Parse query parameters
+	err = app.readQueryParams(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate query parameters
+	violations := validator.New()
+	validateShapeRequest(violations, &req, movieFieldSafeList, movieIncludeSafeList)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
 	// Retrieve the movie record based on the provided ID.
 	movie, err := app.store.GetMovie(ctx, movieID)
 	if err != nil {
@@ -103,8 +214,23 @@ func (app *application) showMovieHandler(ctx *gin.Context) {
 		return
 	}
 
-	rsp := envelope{"movie": movie}
-	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+	etag := movieETag(movie)
+	ctx.Header("ETag", etag)
+
+	// If the client already has the current version of the movie, there is no need to send it again.
+	// The embedded relations may have changed without the movie though.
+	if header := ctx.GetHeader("If-None-Match"); header != "" && len(req.Include) == 0 && etagMatches(header, etag, true) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	relations, err := app.loadMovieRelations(ctx, req.Include, []db.Movie{movie})
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	app.writeShapedJSON(ctx, http.StatusOK, "movie", movie, req, relations[movie.ID], nil)
 }
 
 type updateMovieRequest struct {
@@ -165,6 +291,11 @@ func (app *application) updateMovieHandler(ctx *gin.Context) {
 		return
 	}
 
+	// If the client tells which version it has modified, it must still be the current one.
+	if !app.checkIfMatch(ctx, movieETag(movie)) {
+		return
+	}
+
 	var req updateMovieRequest
 
 	// Parse the request body.
@@ -181,6 +312,15 @@ func (app *application) updateMovieHandler(ctx *gin.Context) {
 		return
 	}
 
+	// The genres must be in the catalogue.
+	if req.Genres != nil {
+		var ok bool
+		req.Genres, ok = app.resolveGenres(ctx, req.Genres)
+		if !ok {
+			return
+		}
+	}
+
 	arg := db.UpdateMovieParams{
 		Title: pgtype.Text{
 			String: util.GetNullableString(req.Title),
@@ -199,10 +339,13 @@ func (app *application) updateMovieHandler(ctx *gin.Context) {
 		Version: movie.Version,
 	}
 
-	// Try to update the movie.
-	updatedMovie, err := app.store.UpdateMovie(ctx, arg)
+	// Try to update the movie, and record the new revision.
+	updatedMovie, err := app.store.UpdateMovieTx(ctx, db.UpdateMovieTxParams{
+		UpdateMovieParams: arg,
+		UserID:            app.contextGetUser(ctx).ID,
+	})
 	if err != nil {
-		// If no matching row could be found, we know the movie's version has changed
+		// If no matching row could be found, we know the movie's version has changed since we retrieved it
 		// (or the record has been deleted) and we we invoke the editConflictResponse method.
 		if errors.Is(err, db.ErrRecordNotFound) {
 			app.editConflictResponse(ctx)
@@ -213,8 +356,10 @@ func (app *application) updateMovieHandler(ctx *gin.Context) {
 		return
 	}
 
+	headers := map[string]string{"ETag": movieETag(updatedMovie)}
+
 	rsp := envelope{"updated_movie": updatedMovie}
-	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+	app.writeJSON(ctx, http.StatusOK, rsp, headers)
 }
 
 // deleteMovieHandler delete a specific movie.
@@ -225,16 +370,47 @@ func (app *application) deleteMovieHandler(ctx *gin.Context) {
 		return
 	}
 
+	arg := db.DeleteMovieTxParams{
+		DeleteMovieParams: db.DeleteMovieParams{ID: movieID},
+		UserID:            app.contextGetUser(ctx).ID,
+	}
+
+	// If the client tells which version it deletes, it must still be the current one.
+	if ctx.GetHeader("If-Match") != "" {
+		movie, err := app.store.GetMovie(ctx, movieID)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				app.notFoundResponse(ctx)
+				return
+			}
+
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+
+		if !app.checkIfMatch(ctx, movieETag(movie)) {
+			return
+		}
+
+		arg.Version = pgtype.Int4{Int32: movie.Version, Valid: true}
+	}
+
 	// Attempt to delete the movie.
-	rowsAffected, err := app.store.DeleteMovie(ctx, movieID)
+	err = app.store.DeleteMovieTx(ctx, arg)
 	if err != nil {
-		app.serverErrorResponse(ctx, err)
-		return
-	}
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// The movie has been modified since we checked its version.
+			if arg.Version.Valid {
+				app.editConflictResponse(ctx)
+				return
+			}
 
-	// If no rows were affected, then the movie with that ID did not exist.
-	if rowsAffected == 0 || rowsAffected != 1 {
-		app.notFoundResponse(ctx)
+			// Otherwise, the movie with that ID did not exist.
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
 		return
 	}
 
@@ -242,42 +418,87 @@ func (app *application) deleteMovieHandler(ctx *gin.Context) {
 	app.writeJSON(ctx, http.StatusOK, rsp, nil)
 }
 
+// movieSortSafeList holds the permitted values of the sort query parameter of the movie lists.
+// The relevance is only available when searching a title, and always sorts the best matches first.
+var movieSortSafeList = []string{"id", "title", "publishYear", "runtime", "rating", "relevance", "-id", "-title", "-publishYear", "-runtime", "-rating"}
+
+// movieSortKey returns the sort key and the direction of a sort value.
+func movieSortKey(sort string) (key string, descending bool) {
+	if sort == "relevance" {
+		return sort, true
+	}
+
+	return strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+}
+
+// movieListItem is a movie of a list, along with how well its title matches the searched one.
+type movieListItem struct {
+	db.Movie
+	MatchScore *float64 `json:"match_score,omitempty"`
+}
+
 type listMoviesRequest struct {
-	Title    string   `form:"title"`
-	Genres   []string `form:"genres"`
+	movieFilters
+	shapeRequest
+	Facets   []string `form:"facets"`
 	Page     *int32   `form:"page"`
 	PageSize *int32   `form:"page_size"`
 	Sort     string   `form:"sort"`
+	Cursor   string   `form:"cursor"`
 }
 
-type listMoviesResponse struct {
-	Metadata db.PaginationMetadata `json:"metadata"`
-	Movies   []db.Movie            `json:"movies"`
+// movieFacetSafeList holds the permitted values of the facets query parameter of the movie list.
+var movieFacetSafeList = []string{"genres", "decade", "runtime"}
+
+// facetValue is the number of movies of the list with a given value of a facet.
+type facetValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
 }
 
-func newListMoviesWithFiltersRowResponse(row db.ListMoviesWithFiltersRow) db.Movie {
-	return db.Movie{
-		ID:          row.Movie.ID,
-		Title:       row.Movie.Title,
-		Runtime:     row.Movie.Runtime,
-		Genres:      row.Movie.Genres,
-		PublishYear: row.Movie.PublishYear,
-		Version:     row.Movie.Version,
-		CreatedAt:   row.Movie.CreatedAt,
+// groupMovieFacets groups the counted values by facet, keeping only the requested facets.
+// A requested facet without any value is still present, with an empty list.
+func groupMovieFacets(rows []db.CountMovieFacetsRow, facets []string) map[string][]facetValue {
+	grouped := make(map[string][]facetValue, len(facets))
+	for _, facet := range facets {
+		grouped[facet] = []facetValue{}
+	}
+
+	for _, row := range rows {
+		values, requested := grouped[row.Facet]
+		if requested {
+			grouped[row.Facet] = append(values, facetValue{Value: row.Value, Count: row.Count})
+		}
 	}
+
+	return grouped
+}
+
+type listMoviesResponse struct {
+	Metadata db.PaginationMetadata   `json:"metadata"`
+	Facets   map[string][]facetValue `json:"facets,omitempty"`
+	Movies   []any                   `json:"movies"`
 }
 
 // validateListMoviesRequest validates the listMoviesRequest struct and sets default "fallback" values if necessary.
 func validateListMoviesRequest(req *listMoviesRequest) validator.Violations {
 	violations := validator.New()
 
-	// If the genres field is not provided, set it to an empty slice.
-	if req.Genres == nil {
-		req.Genres = []string{}
-	} else if req.Genres[0] == "" { // If the genres field is provided but empty, also set it to an empty slice.
-		req.Genres = []string{}
-	} else { // If the genres field is provided, split the comma-separated string into a slice.
-		req.Genres = strings.Split(req.Genres[0], ",")
+	validateMovieFilters(violations, &req.movieFilters)
+
+	validateShapeRequest(violations, &req.shapeRequest, movieListFieldSafeList, movieIncludeSafeList)
+
+	req.Facets = splitListParam(req.Facets)
+	for _, facet := range req.Facets {
+		if !util.PermittedValue(facet, movieFacetSafeList...) {
+			violations.AddError("facets", fmt.Sprintf("invalid facet value <%s>", facet))
+			break
+		}
+	}
+
+	// The page is given by the cursor when it's provided, so the two are mutually exclusive.
+	if req.Cursor != "" && req.Page != nil {
+		violations.AddError("page", "must not be provided along with cursor")
 	}
 
 	if req.Page == nil { // If the page_id is not provided, set it to 1.
@@ -300,16 +521,25 @@ func validateListMoviesRequest(req *listMoviesRequest) validator.Violations {
 	}
 
 	// Check if the sort field is one of the permitted values.
-	sortSafeList := []string{"id", "title", "publishYear", "runtime", "-id", "-title", "-publishYear", "-runtime"}
-	isSortable := util.PermittedValue(req.Sort, sortSafeList...)
+	isSortable := util.PermittedValue(req.Sort, movieSortSafeList...)
 	if !isSortable {
 		violations.AddError("sort", fmt.Sprintf("invalid sort value <%s>", req.Sort))
+	} else if req.Sort == "relevance" && req.Title == "" {
+		violations.AddError("sort", "relevance must be used along with title")
 	}
 
 	return violations
 }
 
 // listMoviesHandler show the details of filtered movies.
+//
+// The movies can be paginated either with page/page_size, or with the opaque cursors returned
+// in the metadata. The cursors don't make the database skip over the previous pages, so they
+// should be preferred to go through a large list.
+//
+// With the facets parameter, the numbers of matching movies per genre, decade or runtime bucket
+// are returned along with the movies. Like for showMovieHandler, the movies can be shaped with
+// the fields and include parameters.
 func (app *application) listMoviesHandler(ctx *gin.Context) {
 	var req listMoviesRequest
 
@@ -322,47 +552,216 @@ func (app *application) listMoviesHandler(ctx *gin.Context) {
 
 	// Validate query parameters
 	violations := validateListMoviesRequest(&req)
+
+	// Decode the cursor, which is only valid for the sort it has been created with.
+	var cursor movieCursor
+	if req.Cursor != "" {
+		cursor, err = app.decodeCursor(req.Cursor)
+		if err != nil {
+			violations.AddError("cursor", err.Error())
+		} else if cursor.Sort != req.Sort {
+			violations.AddError("cursor", "does not match the sort value")
+		}
+	}
+
 	if !violations.Empty() {
 		app.failedValidationResponse(ctx, violations)
 		return
 	}
 
-	arg := db.ListMoviesWithFiltersParams{
-		Title:   req.Title,
-		Genres:  req.Genres,
-		Reverse: strings.HasPrefix(req.Sort, "-"),
-		OrderBy: strings.TrimPrefix(req.Sort, "-"),
-		Limit:   *req.PageSize,
-		Offset:  (*req.Page - 1) * *req.PageSize,
+	// The genres may be given by their names or aliases.
+	if !app.normalizeMovieFilters(ctx, &req.movieFilters) {
+		return
+	}
+
+	// The total number of records is only computed with page-based pagination,
+	// since counting them would defeat the purpose of the cursors.
+	// The cursors keep whether the title is searched with the fuzzy search instead.
+	var totalRecords int64
+	if req.Cursor == "" {
+		totalRecords, err = app.countMovies(ctx, &req.movieFilters)
+		if err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+	} else {
+		req.fuzzy = cursor.Fuzzy
+
+		// The cursor is only valid for the filters it has been created with.
+		if cursor.Filters != req.hash() {
+			violations.AddError("cursor", "does not match the filters")
+			app.failedValidationResponse(ctx, violations)
+			return
+		}
+	}
+
+	// When going backward, the movies are retrieved in the reverse order from the cursor,
+	// then put back in the requested order.
+	sortKey, descending := movieSortKey(req.Sort)
+
+	arg := db.ListMoviesParams{
+		MovieFilters: req.params(),
+		SortKey:      sortKey,
+		Descending:   descending != cursor.Backward,
+		// Retrieve one more movie than the page size to know whether there is another page.
+		Limit: *req.PageSize + 1,
+	}
+
+	if req.Cursor == "" {
+		arg.Offset = (*req.Page - 1) * *req.PageSize
+	} else {
+		cursor.setListParams(&arg)
 	}
 
 	// Retrieve the list of movies based on the provided filters.
-	movies, err := app.store.ListMoviesWithFilters(ctx, arg)
+	rows, err := app.store.ListMovies(ctx, arg)
 	if err != nil {
 		app.serverErrorResponse(ctx, err)
 		return
 	}
 
+	hasMore := len(rows) > int(*req.PageSize)
+	if hasMore {
+		rows = rows[:*req.PageSize]
+	}
+
+	if cursor.Backward {
+		slices.Reverse(rows)
+	}
+
+	listedMovies := make([]db.Movie, 0, len(rows))
+	for _, row := range rows {
+		listedMovies = append(listedMovies, row.Movie)
+	}
+
+	relations, err := app.loadMovieRelations(ctx, req.Include, listedMovies)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	movies := make([]any, 0, len(rows))
+	for _, row := range rows {
+		item := movieListItem{Movie: row.Movie}
+		if req.Title != "" {
+			matchScore := row.MatchScore
+			item.MatchScore = &matchScore
+		}
+
+		movie, err := shapeResource(item, req.Fields, relations[row.Movie.ID])
+		if err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+
+		movies = append(movies, movie)
+	}
+
 	rsp := listMoviesResponse{
 		Metadata: db.PaginationMetadata{},
-		Movies:   make([]db.Movie, 0),
+		Movies:   movies,
+	}
+
+	if req.Cursor == "" {
+		rsp.Metadata = db.CalculatePaginationMetadata(totalRecords, *req.Page, *req.PageSize)
+	} else {
+		rsp.Metadata.PageSize = *req.PageSize
+	}
+
+	// The facets are counted over all the movies matching the filters, not only the current page.
+	if len(req.Facets) > 0 {
+		facets, err := app.store.CountMovieFacets(ctx, db.CountMovieFacetsParams(req.params()))
+		if err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+
+		rsp.Facets = groupMovieFacets(facets, req.Facets)
 	}
 
-	if len(movies) > 0 {
-		rsp.Metadata = db.CalculatePaginationMetadata(movies[0].TotalRecords, *req.Page, *req.PageSize)
-		// TODO: Think about a better solution to handle this.
-		// Instead of using a for loop to just remove the "total_records" field from each movie.
-		// We can use the "-" struct tag to exclude the field from the response.
-		// However, currently, sqlc does not support custom struct tags.
-		for _, v := range movies {
-			rsp.Movies = append(rsp.Movies, newListMoviesWithFiltersRowResponse(v))
+	if len(rows) > 0 {
+		// There is a next page if there are more movies after this one, or if we went backward from it.
+		if (hasMore && !cursor.Backward) || cursor.Backward {
+			rsp.Metadata.NextCursor = app.encodeCursor(newMovieCursor(req.Sort, &req.movieFilters, rows[len(rows)-1], false))
 		}
+
+		// There is a previous page if there are more movies before this one, or if we went forward from it.
+		if (hasMore && cursor.Backward) || (!cursor.Backward && (req.Cursor != "" || *req.Page > 1)) {
+			rsp.Metadata.PrevCursor = app.encodeCursor(newMovieCursor(req.Sort, &req.movieFilters, rows[0], true))
+		}
+	}
+
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+type listDeletedMoviesResponse struct {
+	Metadata db.PaginationMetadata `json:"metadata"`
+	Movies   []db.Movie            `json:"movies"`
+}
+
+// listDeletedMoviesHandler show the movies in the trash, the most recently deleted first.
+func (app *application) listDeletedMoviesHandler(ctx *gin.Context) {
+	var req pageRequest
+
+	// Parse query parameters
+	err := app.readQueryParams(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
 	}
 
-	/*
-		We did scan the dataset into a slice and then loop over it just to exclude the "total_records" field.
-		Double loop can cause performance issues when the dataset is large.
-	*/
+	// Validate query parameters
+	violations := validatePageRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
 
+	rows, err := app.store.ListDeletedMovies(ctx, db.ListDeletedMoviesParams{
+		Offset: (*req.Page - 1) * *req.PageSize,
+		Limit:  *req.PageSize,
+	})
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	var totalRecords int64
+	movies := make([]db.Movie, 0, len(rows))
+	for _, row := range rows {
+		totalRecords = row.TotalRecords
+		movies = append(movies, row.Movie)
+	}
+
+	rsp := listDeletedMoviesResponse{
+		Metadata: db.CalculatePaginationMetadata(totalRecords, *req.Page, *req.PageSize),
+		Movies:   movies,
+	}
 	app.writeJSON(ctx, http.StatusOK, rsp, nil)
 }
+
+// restoreMovieHandler take a specific movie out of the trash.
+func (app *application) restoreMovieHandler(ctx *gin.Context) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	movie, err := app.store.RestoreMovieTx(ctx, movieID, app.contextGetUser(ctx).ID)
+	if err != nil {
+		// If no matching row could be found, the movie is not in the trash.
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	headers := map[string]string{"ETag": movieETag(movie)}
+
+	rsp := envelope{"movie": movie}
+	app.writeJSON(ctx, http.StatusOK, rsp, headers)
+}
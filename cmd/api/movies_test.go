@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/katatrina/greenlight/internal/db"
+)
+
+func TestGroupMovieFacets(t *testing.T) {
+	rows := []db.CountMovieFacetsRow{
+		{Facet: "genres", Value: "drama", Count: 12},
+		{Facet: "genres", Value: "sci-fi", Count: 5},
+		{Facet: "decade", Value: "1990", Count: 7},
+		{Facet: "runtime", Value: "90-120", Count: 9},
+	}
+
+	tests := []struct {
+		name   string
+		rows   []db.CountMovieFacetsRow
+		facets []string
+		want   map[string][]facetValue
+	}{
+		{
+			name:   "no facets",
+			rows:   rows,
+			facets: []string{},
+			want:   map[string][]facetValue{},
+		},
+		{
+			name:   "one facet",
+			rows:   rows,
+			facets: []string{"genres"},
+			want: map[string][]facetValue{
+				"genres": {{Value: "drama", Count: 12}, {Value: "sci-fi", Count: 5}},
+			},
+		},
+		{
+			name:   "all facets",
+			rows:   rows,
+			facets: []string{"genres", "decade", "runtime"},
+			want: map[string][]facetValue{
+				"genres":  {{Value: "drama", Count: 12}, {Value: "sci-fi", Count: 5}},
+				"decade":  {{Value: "1990", Count: 7}},
+				"runtime": {{Value: "90-120", Count: 9}},
+			},
+		},
+		{
+			name:   "facet without values",
+			rows:   nil,
+			facets: []string{"decade"},
+			want:   map[string][]facetValue{"decade": {}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := groupMovieFacets(tt.rows, tt.facets)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupMovieFacets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/katatrina/greenlight/internal/db"
+	"github.com/katatrina/greenlight/internal/util"
+	"github.com/katatrina/greenlight/internal/validator"
+)
+
+type createPersonRequest struct {
+	Name      string  `json:"name"`
+	BirthYear *int32  `json:"birth_year"`
+	Biography *string `json:"biography"`
+}
+
+func validateCreatePersonRequest(req *createPersonRequest) validator.Violations {
+	violations := validator.New()
+
+	if err := validator.ValidatePersonName(req.Name); err != nil {
+		violations.AddError("name", err.Error())
+	}
+
+	if req.BirthYear != nil {
+		if err := validator.ValidatePersonBirthYear(*req.BirthYear); err != nil {
+			violations.AddError("birth_year", err.Error())
+		}
+	}
+
+	if req.Biography != nil {
+		if err := validator.ValidatePersonBiography(*req.Biography); err != nil {
+			violations.AddError("biography", err.Error())
+		}
+	}
+
+	return violations
+}
+
+// createPersonHandler create a new person, who can then be credited in movies.
+func (app *application) createPersonHandler(ctx *gin.Context) {
+	var req createPersonRequest
+
+	// Parse the request body.
+	err := app.readJSON(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate the request body.
+	violations := validateCreatePersonRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	person, err := app.store.CreatePerson(ctx, db.CreatePersonParams{
+		Name: req.Name,
+		BirthYear: pgtype.Int4{
+			Int32: util.GetNullableInt32(req.BirthYear),
+			Valid: req.BirthYear != nil,
+		},
+		Biography: pgtype.Text{
+			String: util.GetNullableString(req.Biography),
+			Valid:  req.Biography != nil,
+		},
+	})
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	headers := make(map[string]string)
+	headers["Location"] = "/v1/people/" + strconv.FormatInt(person.ID, 10)
+
+	rsp := envelope{"person": person}
+	app.writeJSON(ctx, http.StatusCreated, rsp, headers)
+}
+
+// getPerson retrieve the person identified by the "id" URL parameter.
+// If it doesn't exist, an error response is sent and false is returned.
+func (app *application) getPerson(ctx *gin.Context) (db.Person, bool) {
+	personID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return db.Person{}, false
+	}
+
+	person, err := app.store.GetPerson(ctx, personID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return db.Person{}, false
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return db.Person{}, false
+	}
+
+	return person, true
+}
+
+// showPersonHandler show the details of a specific person.
+func (app *application) showPersonHandler(ctx *gin.Context) {
+	person, ok := app.getPerson(ctx)
+	if !ok {
+		return
+	}
+
+	rsp := envelope{"person": person}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+type listPeopleRequest struct {
+	Name string `form:"name"`
+	pageRequest
+}
+
+type listPeopleResponse struct {
+	Metadata db.PaginationMetadata `json:"metadata"`
+	People   []db.Person           `json:"people"`
+}
+
+// listPeopleHandler show the people whose name matches the name parameter, in alphabetical order.
+func (app *application) listPeopleHandler(ctx *gin.Context) {
+	var req listPeopleRequest
+
+	// Parse query parameters
+	err := app.readQueryParams(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate query parameters
+	violations := validatePageRequest(&req.pageRequest)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	rows, err := app.store.ListPeople(ctx, db.ListPeopleParams{
+		Name:   req.Name,
+		Offset: (*req.Page - 1) * *req.PageSize,
+		Limit:  *req.PageSize,
+	})
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	var totalRecords int64
+	people := make([]db.Person, 0, len(rows))
+	for _, row := range rows {
+		totalRecords = row.TotalRecords
+		people = append(people, row.Person)
+	}
+
+	rsp := listPeopleResponse{
+		Metadata: db.CalculatePaginationMetadata(totalRecords, *req.Page, *req.PageSize),
+		People:   people,
+	}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+type updatePersonRequest struct {
+	Name      *string `json:"name"`
+	BirthYear *int32  `json:"birth_year"`
+	Biography *string `json:"biography"`
+}
+
+func validateUpdatePersonRequest(req *updatePersonRequest) validator.Violations {
+	violations := validator.New()
+
+	if req.Name != nil {
+		if err := validator.ValidatePersonName(*req.Name); err != nil {
+			violations.AddError("name", err.Error())
+		}
+	}
+
+	if req.BirthYear != nil {
+		if err := validator.ValidatePersonBirthYear(*req.BirthYear); err != nil {
+			violations.AddError("birth_year", err.Error())
+		}
+	}
+
+	if req.Biography != nil {
+		if err := validator.ValidatePersonBiography(*req.Biography); err != nil {
+			violations.AddError("biography", err.Error())
+		}
+	}
+
+	return violations
+}
+
+// updatePersonHandler update the details of a specific person.
+func (app *application) updatePersonHandler(ctx *gin.Context) {
+	person, ok := app.getPerson(ctx)
+	if !ok {
+		return
+	}
+
+	var req updatePersonRequest
+
+	// Parse the request body.
+	err := app.readJSON(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate the request body.
+	violations := validateUpdatePersonRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	updatedPerson, err := app.store.UpdatePerson(ctx, db.UpdatePersonParams{
+		Name: pgtype.Text{
+			String: util.GetNullableString(req.Name),
+			Valid:  req.Name != nil,
+		},
+		BirthYear: pgtype.Int4{
+			Int32: util.GetNullableInt32(req.BirthYear),
+			Valid: req.BirthYear != nil,
+		},
+		Biography: pgtype.Text{
+			String: util.GetNullableString(req.Biography),
+			Valid:  req.Biography != nil,
+		},
+		ID:      person.ID,
+		Version: person.Version,
+	})
+	if err != nil {
+		// The person has been modified or deleted in the meantime.
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.editConflictResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	rsp := envelope{"updated_person": updatedPerson}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+// deletePersonHandler delete a specific person, who must not be credited in any movie anymore.
+func (app *application) deletePersonHandler(ctx *gin.Context) {
+	personID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	rowsAffected, err := app.store.DeletePerson(ctx, personID)
+	if err != nil {
+		if db.ErrorCode(err) == db.ForeignKeyViolation {
+			app.integrityConstraintViolationResponse(ctx, "the person is still credited in some movies")
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	if rowsAffected == 0 {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	rsp := envelope{"message": "person successfully deleted!"}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+// filmographyEntry is a movie in which a person is credited, along with their credit.
+type filmographyEntry struct {
+	Movie         db.Movie    `json:"movie"`
+	Role          string      `json:"role"`
+	CharacterName pgtype.Text `json:"character_name"`
+	BillingOrder  int32       `json:"billing_order"`
+}
+
+type listPersonFilmographyResponse struct {
+	Metadata    db.PaginationMetadata `json:"metadata"`
+	Filmography []filmographyEntry    `json:"filmography"`
+}
+
+// listPersonFilmographyHandler show the movies in which a specific person is credited, the most recent first.
+// A person credited with several roles in a movie has an entry for each of them.
+func (app *application) listPersonFilmographyHandler(ctx *gin.Context) {
+	person, ok := app.getPerson(ctx)
+	if !ok {
+		return
+	}
+
+	var req pageRequest
+
+	// Parse query parameters
+	err := app.readQueryParams(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate query parameters
+	violations := validatePageRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	rows, err := app.store.ListPersonFilmography(ctx, db.ListPersonFilmographyParams{
+		PersonID: person.ID,
+		Offset:   (*req.Page - 1) * *req.PageSize,
+		Limit:    *req.PageSize,
+	})
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	var totalRecords int64
+	filmography := make([]filmographyEntry, 0, len(rows))
+	for _, row := range rows {
+		totalRecords = row.TotalRecords
+		filmography = append(filmography, filmographyEntry{
+			Movie:         row.Movie,
+			Role:          row.MovieCredit.Role,
+			CharacterName: row.MovieCredit.CharacterName,
+			BillingOrder:  row.MovieCredit.BillingOrder,
+		})
+	}
+
+	rsp := listPersonFilmographyResponse{
+		Metadata:    db.CalculatePaginationMetadata(totalRecords, *req.Page, *req.PageSize),
+		Filmography: filmography,
+	}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
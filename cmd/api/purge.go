@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// purgeDeletedMovies deletes for good the movies which have been in the trash for longer than
+// the retention period, along with the files of their images, every purge interval, until the ctx is done.
+//
+// A purge which has started isn't interrupted by the ctx, so the caller must wait for it to return
+// before closing the database.
+func (app *application) purgeDeletedMovies(ctx context.Context) {
+	ticker := time.NewTicker(app.config.trash.purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := app.store.PurgeDeletedMovies(context.Background(), time.Now().Add(-app.config.trash.retention))
+		if err != nil {
+			app.logger.Error(err.Error(), "job", "purge_deleted_movies")
+			continue
+		}
+
+		var keys []string
+		for _, movie := range purged {
+			keys = append(keys, movie.BlobKeys...)
+		}
+
+		if len(keys) > 0 {
+			app.deleteBlobs(keys...)
+		}
+
+		if len(purged) > 0 {
+			app.logger.Info("purged deleted movies", "count", len(purged), "blobs", len(keys))
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// The clients which haven't been seen for clientIdleTimeout are forgotten, every clientEvictionInterval.
+	clientIdleTimeout      = 3 * time.Minute
+	clientEvictionInterval = time.Minute
+)
+
+// clientLimiters holds a token-bucket rate limiter for each client, identified by a key.
+type clientLimiters struct {
+	mu      sync.Mutex
+	clients map[string]*clientLimiter
+}
+
+// clientLimiter holds the rate limiter and the last seen time of a client.
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newClientLimiters() *clientLimiters {
+	return &clientLimiters{clients: make(map[string]*clientLimiter)}
+}
+
+// get returns the limiter of the client, creating it with the given bucket settings if it's a new client.
+// It must be called with the mutex held.
+func (l *clientLimiters) get(key string, limit rate.Limit, burst int, now time.Time) *rate.Limiter {
+	c, found := l.clients[key]
+	if !found {
+		c = &clientLimiter{limiter: rate.NewLimiter(limit, burst)}
+		l.clients[key] = c
+	}
+
+	c.lastSeen = now
+
+	return c.limiter
+}
+
+// allow takes a token from the bucket of the client, and returns whether there was one,
+// along with the number of tokens left.
+func (l *clientLimiters) allow(key string, limit rate.Limit, burst int, now time.Time) (bool, float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter := l.get(key, limit, burst, now)
+	allowed := limiter.AllowN(now, 1)
+
+	return allowed, limiter.TokensAt(now)
+}
+
+// tokens returns the number of tokens in the bucket of the client, without taking any.
+func (l *clientLimiters) tokens(key string, limit rate.Limit, burst int, now time.Time) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.get(key, limit, burst, now).TokensAt(now)
+}
+
+// charge takes a token from the bucket of the client even if it's empty, so that the client
+// has to wait longer for the bucket to refill.
+func (l *clientLimiters) charge(key string, limit rate.Limit, burst int, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.get(key, limit, burst, now).ReserveN(now, 1)
+}
+
+// evictStale removes the clients which haven't been seen recently, so that the map doesn't grow unbounded.
+// It runs until the ctx is done.
+func (l *clientLimiters) evictStale(ctx context.Context) {
+	ticker := time.NewTicker(clientEvictionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		l.mu.Lock()
+		for key, c := range l.clients {
+			if time.Since(c.lastSeen) > clientIdleTimeout {
+				delete(l.clients, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
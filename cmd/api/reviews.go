@@ -0,0 +1,295 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/katatrina/greenlight/internal/db"
+	"github.com/katatrina/greenlight/internal/util"
+	"github.com/katatrina/greenlight/internal/validator"
+)
+
+type createReviewRequest struct {
+	Score *int32  `json:"score"`
+	Body  *string `json:"body"`
+}
+
+func validateCreateReviewRequest(req *createReviewRequest) validator.Violations {
+	violations := validator.New()
+
+	if req.Score == nil {
+		violations.AddError("score", "must be provided")
+	} else if err := validator.ValidateReviewScore(*req.Score); err != nil {
+		violations.AddError("score", err.Error())
+	}
+
+	if req.Body != nil {
+		if err := validator.ValidateReviewBody(*req.Body); err != nil {
+			violations.AddError("body", err.Error())
+		}
+	}
+
+	return violations
+}
+
+// createReviewHandler create a review of a specific movie by the authenticated user.
+func (app *application) createReviewHandler(ctx *gin.Context) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	var req createReviewRequest
+
+	// Parse the request body.
+	err = app.readJSON(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate the request body.
+	violations := validateCreateReviewRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	user := app.contextGetUser(ctx)
+
+	// Create the review and update the rating of the movie.
+	review, err := app.store.CreateReviewTx(ctx, db.CreateReviewParams{
+		MovieID: movieID,
+		UserID:  user.ID,
+		Score:   *req.Score,
+		Body: pgtype.Text{
+			String: util.GetNullableString(req.Body),
+			Valid:  req.Body != nil,
+		},
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			app.notFoundResponse(ctx)
+		// A user can only review a movie once.
+		case db.ErrorCode(err) == db.UniqueViolation && db.IsContainErrorMessage(err, "reviews_movie_id_user_id_key"):
+			app.integrityConstraintViolationResponse(ctx, "you have already reviewed this movie")
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	headers := make(map[string]string)
+	headers["Location"] = fmt.Sprintf("/v1/movies/%d/reviews/%d", movieID, review.ID)
+
+	rsp := envelope{"review": review}
+	app.writeJSON(ctx, http.StatusCreated, rsp, headers)
+}
+
+type listMovieReviewsResponse struct {
+	Metadata db.PaginationMetadata `json:"metadata"`
+	Reviews  []db.Review           `json:"reviews"`
+}
+
+// listMovieReviewsHandler show the reviews of a specific movie, the most recent first.
+func (app *application) listMovieReviewsHandler(ctx *gin.Context) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	var req pageRequest
+
+	// Parse query parameters
+	err = app.readQueryParams(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate query parameters
+	violations := validatePageRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	// Make sure the movie exists, so that we don't return an empty list for a movie that doesn't.
+	_, err = app.store.GetMovie(ctx, movieID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	rows, err := app.store.ListMovieReviews(ctx, db.ListMovieReviewsParams{
+		MovieID: movieID,
+		Offset:  (*req.Page - 1) * *req.PageSize,
+		Limit:   *req.PageSize,
+	})
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	var totalRecords int64
+	reviews := make([]db.Review, 0, len(rows))
+	for _, row := range rows {
+		totalRecords = row.TotalRecords
+		reviews = append(reviews, row.Review)
+	}
+
+	rsp := listMovieReviewsResponse{
+		Metadata: db.CalculatePaginationMetadata(totalRecords, *req.Page, *req.PageSize),
+		Reviews:  reviews,
+	}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+// getAuthoredReview retrieve the review identified by the "id" and "review_id" URL parameters,
+// and check that it has been written by the authenticated user.
+// If it's not the case, an error response is sent and false is returned.
+func (app *application) getAuthoredReview(ctx *gin.Context) (db.Review, bool) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return db.Review{}, false
+	}
+
+	reviewID, err := app.readNamedIDParam(ctx, "review_id")
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return db.Review{}, false
+	}
+
+	review, err := app.store.GetReview(ctx, reviewID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return db.Review{}, false
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return db.Review{}, false
+	}
+
+	// The review must belong to the movie in the URL.
+	if review.MovieID != movieID {
+		app.notFoundResponse(ctx)
+		return db.Review{}, false
+	}
+
+	if review.UserID != app.contextGetUser(ctx).ID {
+		app.notReviewAuthorResponse(ctx)
+		return db.Review{}, false
+	}
+
+	return review, true
+}
+
+type updateReviewRequest struct {
+	Score *int32  `json:"score"`
+	Body  *string `json:"body"`
+}
+
+func validateUpdateReviewRequest(req *updateReviewRequest) validator.Violations {
+	violations := validator.New()
+
+	if req.Score != nil {
+		if err := validator.ValidateReviewScore(*req.Score); err != nil {
+			violations.AddError("score", err.Error())
+		}
+	}
+
+	if req.Body != nil {
+		if err := validator.ValidateReviewBody(*req.Body); err != nil {
+			violations.AddError("body", err.Error())
+		}
+	}
+
+	return violations
+}
+
+// updateReviewHandler update a review written by the authenticated user.
+func (app *application) updateReviewHandler(ctx *gin.Context) {
+	review, ok := app.getAuthoredReview(ctx)
+	if !ok {
+		return
+	}
+
+	var req updateReviewRequest
+
+	// Parse the request body.
+	err := app.readJSON(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate the request body.
+	violations := validateUpdateReviewRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	// Update the review and the rating of the movie.
+	updatedReview, err := app.store.UpdateReviewTx(ctx, db.UpdateReviewParams{
+		Score: pgtype.Int4{
+			Int32: util.GetNullableInt32(req.Score),
+			Valid: req.Score != nil,
+		},
+		Body: pgtype.Text{
+			String: util.GetNullableString(req.Body),
+			Valid:  req.Body != nil,
+		},
+		ID:      review.ID,
+		Version: review.Version,
+	})
+	if err != nil {
+		// The review has been modified or deleted in the meantime.
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.editConflictResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	rsp := envelope{"updated_review": updatedReview}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+// deleteReviewHandler delete a review written by the authenticated user.
+func (app *application) deleteReviewHandler(ctx *gin.Context) {
+	review, ok := app.getAuthoredReview(ctx)
+	if !ok {
+		return
+	}
+
+	// Delete the review and update the rating of the movie.
+	err := app.store.DeleteReviewTx(ctx, review)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	rsp := envelope{"message": "review successfully deleted!"}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/katatrina/greenlight/internal/db"
+)
+
+// movieSnapshot is the state of a movie recorded in its revisions.
+// Unlike in the movie responses, the runtime is a plain number of minutes.
+type movieSnapshot struct {
+	Title       string   `json:"title"`
+	PublishYear int32    `json:"publish_year"`
+	Runtime     int32    `json:"runtime"`
+	Genres      []string `json:"genres"`
+}
+
+// revisionChange holds the values of a field before and after a revision.
+type revisionChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+type movieRevisionResponse struct {
+	Version   int32                     `json:"version"`
+	Action    string                    `json:"action"`
+	UserID    pgtype.Int8               `json:"user_id"`
+	CreatedAt time.Time                 `json:"created_at"`
+	Snapshot  json.RawMessage           `json:"snapshot"`
+	Changes   map[string]revisionChange `json:"changes"`
+}
+
+type listMovieRevisionsResponse struct {
+	Metadata  db.PaginationMetadata   `json:"metadata"`
+	Revisions []movieRevisionResponse `json:"revisions"`
+}
+
+// diffSnapshots returns the fields which differ between two snapshots.
+// The previous snapshot is nil for the first revision, so all its fields are changes.
+func diffSnapshots(previous, current json.RawMessage) (map[string]revisionChange, error) {
+	var previousFields, currentFields map[string]json.RawMessage
+
+	if previous != nil {
+		if err := json.Unmarshal(previous, &previousFields); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := json.Unmarshal(current, &currentFields); err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]revisionChange)
+	for field, value := range currentFields {
+		if !bytes.Equal(previousFields[field], value) {
+			changes[field] = revisionChange{From: previousFields[field], To: value}
+		}
+	}
+
+	// A field may also have been removed from the snapshots.
+	for field, value := range previousFields {
+		if _, ok := currentFields[field]; !ok {
+			changes[field] = revisionChange{From: value}
+		}
+	}
+
+	return changes, nil
+}
+
+// listMovieRevisionsHandler show the history of a specific movie, the most recent revision first.
+// Each revision lists the fields it has changed from the previous one.
+func (app *application) listMovieRevisionsHandler(ctx *gin.Context) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	var req pageRequest
+
+	// Parse query parameters
+	err = app.readQueryParams(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate query parameters
+	violations := validatePageRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	rows, err := app.store.ListMovieRevisions(ctx, db.ListMovieRevisionsParams{
+		MovieID: movieID,
+		Offset:  (*req.Page - 1) * *req.PageSize,
+		Limit:   *req.PageSize,
+	})
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	// Every movie has at least one revision, including the deleted ones.
+	if len(rows) == 0 && *req.Page == 1 {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	var totalRecords int64
+	revisions := make([]movieRevisionResponse, 0, len(rows))
+	for _, row := range rows {
+		totalRecords = row.TotalRecords
+
+		changes, err := diffSnapshots(row.PreviousSnapshot, row.MovieRevision.Snapshot)
+		if err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+
+		revisions = append(revisions, movieRevisionResponse{
+			Version:   row.MovieRevision.Version,
+			Action:    row.MovieRevision.Action,
+			UserID:    row.MovieRevision.UserID,
+			CreatedAt: row.MovieRevision.CreatedAt,
+			Snapshot:  row.MovieRevision.Snapshot,
+			Changes:   changes,
+		})
+	}
+
+	rsp := listMovieRevisionsResponse{
+		Metadata:  db.CalculatePaginationMetadata(totalRecords, *req.Page, *req.PageSize),
+		Revisions: revisions,
+	}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+// revertMovieHandler update a specific movie with the values of one of its revisions.
+// The revert is a new version of the movie, so it's also recorded as a revision.
+func (app *application) revertMovieHandler(ctx *gin.Context) {
+	movieID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	version, err := app.readNamedIDParam(ctx, "version")
+	if err != nil || version > math.MaxInt32 {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	revision, err := app.store.GetMovieRevision(ctx, db.GetMovieRevisionParams{
+		MovieID: movieID,
+		Version: int32(version),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	var snapshot movieSnapshot
+	err = json.Unmarshal(revision.Snapshot, &snapshot)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	// The genres of the revision may have been renamed or merged since then.
+	var ok bool
+	snapshot.Genres, ok = app.resolveGenres(ctx, snapshot.Genres)
+	if !ok {
+		return
+	}
+
+	// Retrieve the current version of the movie. A deleted movie must be restored before being reverted.
+	movie, err := app.store.GetMovie(ctx, movieID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	if !app.checkIfMatch(ctx, movieETag(movie)) {
+		return
+	}
+
+	updatedMovie, err := app.store.UpdateMovieTx(ctx, db.UpdateMovieTxParams{
+		UpdateMovieParams: db.UpdateMovieParams{
+			Title:       pgtype.Text{String: snapshot.Title, Valid: true},
+			PublishYear: pgtype.Int4{Int32: snapshot.PublishYear, Valid: true},
+			Runtime:     pgtype.Int4{Int32: snapshot.Runtime, Valid: true},
+			Genres:      snapshot.Genres,
+			ID:          movieID,
+			Version:     movie.Version,
+		},
+		UserID: app.contextGetUser(ctx).ID,
+		Action: db.RevisionActionRevert,
+	})
+	if err != nil {
+		// If no matching row could be found, the movie has been modified in the meantime.
+		if errors.Is(err, db.ErrRecordNotFound) {
+			app.editConflictResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	headers := map[string]string{"ETag": movieETag(updatedMovie)}
+
+	rsp := envelope{"updated_movie": updatedMovie}
+	app.writeJSON(ctx, http.StatusOK, rsp, headers)
+}
@@ -7,28 +7,81 @@ import (
 )
 
 const (
-	movieReadPermissionCode  = "movies:read"
-	movieWritePermissionCode = "movies:write"
+	movieReadPermissionCode   = "movies:read"
+	movieWritePermissionCode  = "movies:write"
+	metricsViewPermissionCode = "metrics:view"
+	reviewWritePermissionCode = "reviews:write"
+	genreWritePermissionCode  = "genres:write"
 )
 
 func (app *application) routes() http.Handler {
 	// Initialize a new gin router instance.
-	router := gin.Default()
+	// We use gin.New() rather than gin.Default(), since we log the requests and recover from the panics
+	// with our own middlewares.
+	router := gin.New()
 	router.HandleMethodNotAllowed = true
 	router.NoMethod(app.methodNotAllowedResponse)
 	router.NoRoute(app.notFoundResponse)
 
-	router.Use(app.authenticate()) // we want to authenticate user on all requests.
+	router.Use(app.requestID())
+	router.Use(app.logRequest())
+	router.Use(app.recordMetrics()) // the metrics are recorded outside recoverPanic, so that they count the panics as 500s.
+	router.Use(app.recoverPanic())
+	router.Use(app.enableCORS())
+	router.Use(app.rateLimitByIP())   // the anonymous requests and the invalid credentials are limited before hitting the database.
+	router.Use(app.authenticate())    // we want to authenticate user on all requests.
+	router.Use(app.rateLimitByUser()) // the authenticated users are limited by their user ID, so it comes after.
 
 	router.GET("/v1/healthcheck", app.healthcheckHandler)
+	router.GET("/v1/healthcheck/live", app.livenessHandler)
+	router.GET("/v1/healthcheck/ready", app.readinessHandler)
+
+	router.GET("/debug/metrics",
+		app.requireAuthenticatedUser(), app.requireActivatedUser(), app.requirePermission(metricsViewPermissionCode),
+		app.metricsHandler)
 
 	movieRoutes := router.Group("/v1/movies", app.requireAuthenticatedUser(), app.requireActivatedUser())
 	{
 		movieRoutes.POST("", app.requirePermission(movieWritePermissionCode), app.createMovieHandler)
+		movieRoutes.POST("/import", app.requirePermission(movieWritePermissionCode), app.importMoviesHandler)
 		movieRoutes.GET("/:id", app.requirePermission(movieReadPermissionCode), app.showMovieHandler)
 		movieRoutes.GET("", app.requirePermission(movieReadPermissionCode), app.listMoviesHandler)
+		movieRoutes.GET("/export", app.requirePermission(movieReadPermissionCode), app.exportMoviesHandler)
+		movieRoutes.GET("/trash", app.requirePermission(movieWritePermissionCode), app.listDeletedMoviesHandler)
 		movieRoutes.PATCH("/:id", app.requirePermission(movieWritePermissionCode), app.updateMovieHandler)
 		movieRoutes.DELETE("/:id", app.requirePermission(movieWritePermissionCode), app.deleteMovieHandler)
+		movieRoutes.POST("/:id/restore", app.requirePermission(movieWritePermissionCode), app.restoreMovieHandler)
+		movieRoutes.GET("/:id/revisions", app.requirePermission(movieReadPermissionCode), app.listMovieRevisionsHandler)
+		movieRoutes.POST("/:id/revisions/:version/revert", app.requirePermission(movieWritePermissionCode), app.revertMovieHandler)
+		movieRoutes.GET("/:id/credits", app.requirePermission(movieReadPermissionCode), app.listMovieCreditsHandler)
+		movieRoutes.PUT("/:id/credits", app.requirePermission(movieWritePermissionCode), app.replaceMovieCreditsHandler)
+		movieRoutes.GET("/:id/images", app.requirePermission(movieReadPermissionCode), app.listMovieImagesHandler)
+		movieRoutes.POST("/:id/images", app.requirePermission(movieWritePermissionCode), app.uploadMovieImageHandler)
+		movieRoutes.GET("/:id/images/:image_id", app.requirePermission(movieReadPermissionCode), app.serveMovieImageHandler)
+		movieRoutes.DELETE("/:id/images/:image_id", app.requirePermission(movieWritePermissionCode), app.deleteMovieImageHandler)
+
+		movieRoutes.POST("/:id/reviews", app.requirePermission(reviewWritePermissionCode), app.createReviewHandler)
+		movieRoutes.GET("/:id/reviews", app.requirePermission(movieReadPermissionCode), app.listMovieReviewsHandler)
+		movieRoutes.PATCH("/:id/reviews/:review_id", app.requirePermission(reviewWritePermissionCode), app.updateReviewHandler)
+		movieRoutes.DELETE("/:id/reviews/:review_id", app.requirePermission(reviewWritePermissionCode), app.deleteReviewHandler)
+	}
+
+	peopleRoutes := router.Group("/v1/people", app.requireAuthenticatedUser(), app.requireActivatedUser())
+	{
+		peopleRoutes.POST("", app.requirePermission(movieWritePermissionCode), app.createPersonHandler)
+		peopleRoutes.GET("", app.requirePermission(movieReadPermissionCode), app.listPeopleHandler)
+		peopleRoutes.GET("/:id", app.requirePermission(movieReadPermissionCode), app.showPersonHandler)
+		peopleRoutes.PATCH("/:id", app.requirePermission(movieWritePermissionCode), app.updatePersonHandler)
+		peopleRoutes.DELETE("/:id", app.requirePermission(movieWritePermissionCode), app.deletePersonHandler)
+		peopleRoutes.GET("/:id/filmography", app.requirePermission(movieReadPermissionCode), app.listPersonFilmographyHandler)
+	}
+
+	genreRoutes := router.Group("/v1/genres", app.requireAuthenticatedUser(), app.requireActivatedUser())
+	{
+		genreRoutes.GET("", app.requirePermission(movieReadPermissionCode), app.listGenresHandler)
+		genreRoutes.POST("", app.requirePermission(genreWritePermissionCode), app.createGenreHandler)
+		genreRoutes.PATCH("/:slug", app.requirePermission(genreWritePermissionCode), app.updateGenreHandler)
+		genreRoutes.POST("/:slug/merge", app.requirePermission(genreWritePermissionCode), app.mergeGenreHandler)
 	}
 
 	userRoutes := router.Group("/v1/users")
@@ -38,6 +91,18 @@ func (app *application) routes() http.Handler {
 		userRoutes.PUT("/password/reset", app.resetUserPasswordHandler)
 	}
 
+	// The routes under /v1/users/me are about the authenticated user's own data.
+	meRoutes := router.Group("/v1/users/me", app.requireAuthenticatedUser(), app.requireActivatedUser(), app.requirePermission(movieReadPermissionCode))
+	{
+		meRoutes.GET("/watchlist", app.listWatchlistHandler)
+		meRoutes.POST("/watchlist", app.addToWatchlistHandler)
+		meRoutes.PUT("/watchlist/order", app.reorderWatchlistHandler)
+		meRoutes.DELETE("/watchlist/:movie_id", app.removeFromWatchlistHandler)
+		meRoutes.GET("/watched", app.listWatchedHandler)
+		meRoutes.POST("/watched", app.logWatchedMovieHandler)
+		meRoutes.DELETE("/watched/:id", app.deleteWatchedEntryHandler)
+	}
+
 	tokenRoutes := router.Group("/v1/tokens")
 	{
 		tokenRoutes.POST("/authentication", app.createAuthenticationTokenHandler) // login
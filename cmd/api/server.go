@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// serve starts the HTTP server and blocks until it has been shut down gracefully.
+//
+// When a SIGINT or SIGTERM signal is received, the server stops accepting new connections,
+// waits for in-flight requests to finish and then waits for the background tasks,
+// all within the configured shutdown grace period.
+func (app *application) serve() error {
+	// Declare a HTTP server which listens on the port provided in the config struct,
+	// uses the router we created as the handler, has some sensible timeout
+	// settings and writes any log messages to the structured logger at Error level.
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", app.config.port),
+		Handler:      app.routes(),
+		IdleTimeout:  app.config.server.idleTimeout,
+		ReadTimeout:  app.config.server.readTimeout,
+		WriteTimeout: app.config.server.writeTimeout,
+		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
+	}
+
+	// The TLS certificate reloader and the periodic jobs are stopped once the server has been shut down.
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+
+	// The purge job is tracked apart from the background tasks, which it may start itself. It's stopped and
+	// waited for whichever way serve returns, so that a purge in progress doesn't outlive the connection pool.
+	var jobs sync.WaitGroup
+	stopJobs := func() {
+		stopWatching()
+		jobs.Wait()
+	}
+	defer stopJobs()
+
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		app.purgeDeletedMovies(watchCtx)
+	}()
+
+	go app.ipLimiters.evictStale(watchCtx)
+	go app.userLimiters.evictStale(watchCtx)
+
+	// redirectSrv is the optional plain HTTP server redirecting to the HTTPS server.
+	var redirectSrv *http.Server
+
+	tlsEnabled := app.config.tls.certFile != ""
+	if tlsEnabled {
+		reloader, err := newCertReloader(app.config.tls.certFile, app.config.tls.keyFile, app.logger)
+		if err != nil {
+			return err
+		}
+
+		srv.TLSConfig, err = app.newTLSConfig(reloader)
+		if err != nil {
+			return err
+		}
+
+		go reloader.watch(watchCtx, app.config.tls.reloadInterval)
+
+		if app.config.tls.redirectPort != 0 {
+			redirectSrv = app.newRedirectServer()
+
+			go func() {
+				app.logger.Info("redirect server is listening", "addr", redirectSrv.Addr)
+
+				err := redirectSrv.ListenAndServe()
+				if !errors.Is(err, http.ErrServerClosed) {
+					app.logger.Error(err.Error(), "addr", redirectSrv.Addr)
+				}
+			}()
+		}
+	}
+
+	// shutdownError receives any errors returned by the graceful shutdown process.
+	shutdownError := make(chan error)
+
+	go func() {
+		// Block until a SIGINT or SIGTERM signal is caught.
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		s := <-quit
+
+		app.logger.Info("shutting down server", "signal", s.String())
+
+		// The background tasks finishing while the in-flight requests are drained are counted as finished too.
+		finishedBefore := app.finishedTasks.Load()
+
+		// Give in-flight requests and background tasks the configured grace period to complete.
+		ctx, cancel := context.WithTimeout(context.Background(), app.config.shutdownTimeout)
+		defer cancel()
+
+		if redirectSrv != nil {
+			err := redirectSrv.Shutdown(ctx)
+			if err != nil {
+				app.logger.Error(err.Error(), "addr", redirectSrv.Addr)
+			}
+		}
+
+		// Shutdown() returns nil if the graceful shutdown was successful, or an error
+		// if the grace period expired before all the connections were closed.
+		// Either way, the background tasks are waited for, or at least reported as abandoned.
+		err := srv.Shutdown(ctx)
+
+		// Stop the periodic jobs and wait for the purge in progress, if any, since it may still start background tasks.
+		stopJobs()
+
+		app.logger.Info("completing background tasks", "addr", srv.Addr)
+
+		app.waitForBackgroundTasks(ctx, finishedBefore)
+
+		shutdownError <- err
+	}()
+
+	app.logger.Info("server is listening", "addr", srv.Addr, "env", app.config.env, "tls", tlsEnabled)
+
+	// Calling Shutdown() causes ListenAndServe() to immediately return an http.ErrServerClosed error,
+	// so any other error means that the server failed to start or stopped unexpectedly.
+	// The certificate is provided by the TLS config, so no file is passed to ListenAndServeTLS().
+	var err error
+	if tlsEnabled {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	// Wait until the graceful shutdown process has completed.
+	err = <-shutdownError
+	if err != nil {
+		return err
+	}
+
+	app.logger.Info("stopped server", "addr", srv.Addr)
+
+	return nil
+}
+
+// waitForBackgroundTasks blocks until all the background tasks have finished or the ctx is done,
+// then logs how many tasks have finished since finishedBefore was read, and how many were abandoned.
+func (app *application) waitForBackgroundTasks(ctx context.Context, finishedBefore int64) {
+	done := make(chan struct{})
+	go func() {
+		app.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		app.logger.Info("background tasks completed", "finished", app.finishedTasks.Load()-finishedBefore, "abandoned", 0)
+	case <-ctx.Done():
+		app.logger.Warn("background tasks abandoned", "finished", app.finishedTasks.Load()-finishedBefore, "abandoned", app.backgroundTasks.Load())
+	}
+}
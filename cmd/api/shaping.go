@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/katatrina/greenlight/internal/util"
+	"github.com/katatrina/greenlight/internal/validator"
+)
+
+// shapeRequest holds the query parameters which shape the resources of a response:
+// fields trims them to some of their fields, and include embeds some of their relations.
+type shapeRequest struct {
+	Fields  []string `form:"fields"`
+	Include []string `form:"include"`
+}
+
+// validateShapeRequest validates the shapeRequest struct against the JSON fields and the relations of a resource.
+func validateShapeRequest(violations validator.Violations, req *shapeRequest, fieldSafeList, includeSafeList []string) {
+	req.Fields = splitListParam(req.Fields)
+	for _, field := range req.Fields {
+		if !util.PermittedValue(field, fieldSafeList...) {
+			violations.AddError("fields", fmt.Sprintf("invalid field value <%s>", field))
+			break
+		}
+	}
+
+	req.Include = splitListParam(req.Include)
+	for _, relation := range req.Include {
+		if !util.PermittedValue(relation, includeSafeList...) {
+			violations.AddError("include", fmt.Sprintf("invalid include value <%s>", relation))
+			break
+		}
+	}
+}
+
+// shapeResource returns the JSON object of a resource, trimmed to the given fields,
+// with the embedded relations added to it. Without fields, all the fields of the resource are kept.
+func shapeResource(resource any, fields []string, embedded map[string]any) (any, error) {
+	if len(fields) == 0 && len(embedded) == 0 {
+		return resource, nil
+	}
+
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]json.RawMessage
+	err = json.Unmarshal(data, &object)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) > 0 {
+		for field := range object {
+			if !slices.Contains(fields, field) {
+				delete(object, field)
+			}
+		}
+	}
+
+	for relation, value := range embedded {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		object[relation] = data
+	}
+
+	return object, nil
+}
+
+// writeShapedJSON is like writeJSON for a response made of a single resource under key,
+// which is shaped according to req. embedded holds the relations of the resource listed in req.Include.
+func (app *application) writeShapedJSON(ctx *gin.Context, statusCode int, key string, resource any, req shapeRequest, embedded map[string]any, headers map[string]string) {
+	shaped, err := shapeResource(resource, req.Fields, embedded)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	app.writeJSON(ctx, statusCode, envelope{key: shaped}, headers)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/katatrina/greenlight/internal/validator"
+)
+
+func TestValidateShapeRequestMovieFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    string
+		include   string
+		safeList  []string
+		wantField string // The field of the expected violation, if any.
+	}{
+		{"show fields", "id,title,average_rating", "", movieFieldSafeList, ""},
+		{"show match score", "id,match_score", "", movieFieldSafeList, "fields"},
+		{"list match score", "id,match_score", "", movieListFieldSafeList, ""},
+		{"unknown field", "id,budget", "", movieListFieldSafeList, "fields"},
+		{"relations", "", "reviews_summary,revision_count", movieFieldSafeList, ""},
+		{"unknown relation", "", "cast", movieFieldSafeList, "include"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := validator.New()
+			req := shapeRequest{Fields: []string{tt.fields}, Include: []string{tt.include}}
+			validateShapeRequest(violations, &req, tt.safeList, movieIncludeSafeList)
+
+			if tt.wantField == "" {
+				if !violations.Empty() {
+					t.Errorf("validateShapeRequest() = %v, want no violations", violations)
+				}
+				return
+			}
+
+			if _, ok := violations[tt.wantField]; !ok || len(violations) != 1 {
+				t.Errorf("validateShapeRequest() = %v, want a violation of %q", violations, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	_ "image/png" // register the PNG decoder
+	"io"
+)
+
+const thumbnailQuality = 80
+
+// newThumbnail decodes a JPEG or PNG image and returns a JPEG copy of it which fits in a
+// dimension × dimension square. Each pixel of the thumbnail is the average of the pixels
+// of the box it covers in the image, which is good enough for a downscale.
+// An image which already fits is only re-encoded.
+func newThumbnail(r io.Reader, dimension int) ([]byte, error) {
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Keep the aspect ratio of the image.
+	thumbWidth, thumbHeight := width, height
+	if width > dimension || height > dimension {
+		if width >= height {
+			thumbWidth, thumbHeight = dimension, max(1, height*dimension/width)
+		} else {
+			thumbWidth, thumbHeight = max(1, width*dimension/height), dimension
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := 0; y < thumbHeight; y++ {
+		y0 := bounds.Min.Y + y*height/thumbHeight
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/thumbHeight)
+
+		for x := 0; x < thumbWidth; x++ {
+			x0 := bounds.Min.X + x*width/thumbWidth
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/thumbWidth)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			// RGBA() returns 16-bit channels, while the RGBA image holds 8-bit ones.
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
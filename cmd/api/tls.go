@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// tlsVersions maps the accepted values of the tls-min-version setting to their TLS versions.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newTLSConfig creates the TLS configuration of the server from the config struct.
+// The certificate is provided by the reloader, so that it can be replaced without a restart.
+func (app *application) newTLSConfig(reloader *certReloader) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:     tlsVersions[app.config.tls.minVersion],
+		GetCertificate: reloader.getCertificate,
+		// Advertise HTTP/2 first, so that the clients supporting it use it.
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+
+	// The cipher suites only apply to TLS 1.2, since the TLS 1.3 ones are not configurable.
+	for _, name := range app.config.tls.cipherSuites {
+		id, ok := cipherSuiteID(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown TLS cipher suite %q", name)
+		}
+
+		tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+	}
+
+	return tlsConfig, nil
+}
+
+// cipherSuiteID returns the ID of a secure cipher suite from its name.
+func cipherSuiteID(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+
+	return 0, false
+}
+
+// newRedirectServer creates a plain HTTP server which redirects every request to the HTTPS server.
+func (app *application) newRedirectServer() *http.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+
+		if app.config.port != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(app.config.port))
+		}
+
+		// 308 Permanent Redirect makes the client repeat the request with the same method and body.
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+
+	return &http.Server{
+		Addr:         fmt.Sprintf(":%d", app.config.tls.redirectPort),
+		Handler:      handler,
+		IdleTimeout:  app.config.server.idleTimeout,
+		ReadTimeout:  app.config.server.readTimeout,
+		WriteTimeout: app.config.server.writeTimeout,
+		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
+	}
+}
+
+// certReloader holds the TLS certificate of the server, and reloads it from disk
+// when the certificate files change or when a SIGHUP signal is received.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// newCertReloader creates a certReloader, and loads the certificate for the first time.
+func newCertReloader(certFile, keyFile string, logger *slog.Logger) (*certReloader, error) {
+	reloader := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+
+	err := reloader.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// reload loads the certificate and key files, and replaces the current certificate
+// only if they are valid, so that a half-written file doesn't break the server.
+func (reloader *certReloader) reload() error {
+	modTime, err := reloader.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	reloader.mu.Lock()
+	reloader.cert = &cert
+	reloader.modTime = modTime
+	reloader.mu.Unlock()
+
+	return nil
+}
+
+// latestModTime returns the latest modification time of the certificate and key files.
+func (reloader *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, file := range []string{reloader.certFile, reloader.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// getCertificate returns the current certificate. It is used as the tls.Config.GetCertificate callback.
+func (reloader *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	reloader.mu.RLock()
+	defer reloader.mu.RUnlock()
+
+	return reloader.cert, nil
+}
+
+// watch reloads the certificate on SIGHUP, or when the files have been modified since the last
+// reload (checked every interval). It blocks until the ctx is done.
+func (reloader *certReloader) watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-hup:
+			reloader.logger.Info("reloading TLS certificate", "reason", "SIGHUP")
+
+		case <-ticker.C:
+			modTime, err := reloader.latestModTime()
+			if err != nil {
+				reloader.logger.Error(err.Error())
+				continue
+			}
+
+			reloader.mu.RLock()
+			changed := modTime.After(reloader.modTime)
+			reloader.mu.RUnlock()
+
+			if !changed {
+				continue
+			}
+
+			reloader.logger.Info("reloading TLS certificate", "reason", "files changed")
+		}
+
+		err := reloader.reload()
+		if err != nil {
+			reloader.logger.Error(err.Error())
+			continue
+		}
+
+		reloader.logger.Info("TLS certificate reloaded")
+	}
+}
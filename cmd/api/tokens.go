@@ -79,10 +79,10 @@ func (app *application) createAuthenticationTokenHandler(ctx *gin.Context) {
 	}
 
 	// If the password is correct, we generate a new stateful authentication token
-	// with a 24-hour expiry time with the scope 'authentication'.
+	// with the configured expiry time with the scope 'authentication'.
 	tokenPlaintext, token, err := app.store.GenerateToken(ctx, db.GenerateTokenParams{
 		UserID:   user.ID,
-		Duration: 24 * time.Hour,
+		Duration: app.config.tokens.authenticationTTL,
 		Scope:    db.ScopeAuthentication,
 	})
 	if err != nil {
@@ -152,10 +152,10 @@ func (app *application) createPasswordResetTokenHandler(ctx *gin.Context) {
 		return
 	}
 
-	// Otherwise, create a new password reset token with a 45-minute expiry time.
+	// Otherwise, create a new password reset token with the configured expiry time.
 	tokenPlaintext, _, err := app.store.GenerateToken(ctx, db.GenerateTokenParams{
 		UserID:   user.ID,
-		Duration: 45 * time.Minute,
+		Duration: app.config.tokens.passwordResetTTL,
 		Scope:    db.ScopePasswordReset,
 	})
 	if err != nil {
@@ -164,6 +164,7 @@ func (app *application) createPasswordResetTokenHandler(ctx *gin.Context) {
 	}
 
 	// Send an email to the user with the password reset token.
+	requestID := app.contextGetRequestID(ctx)
 	app.background(func() {
 		header := mailer.EmailHeader{
 			Subject: "Reset your Greenlight password",
@@ -174,10 +175,7 @@ func (app *application) createPasswordResetTokenHandler(ctx *gin.Context) {
 			"passwordResetToken": tokenPlaintext,
 		}
 
-		err := app.mailer.SendEmail(header, data, "token_password_rest.html")
-		if err != nil {
-			app.logger.Error(err.Error())
-		}
+		app.sendEmail(requestID, header, data, "token_password_rest.html")
 	})
 
 	// Send a 202 Accepted response and confirmation message to the client.
@@ -238,10 +236,10 @@ func (app *application) createActivationTokenHandler(ctx *gin.Context) {
 		return
 	}
 
-	// Otherwise, create a new activation token with a 3-day expiry time.
+	// Otherwise, create a new activation token with the configured expiry time.
 	tokenPlaintext, _, err := app.store.GenerateToken(ctx, db.GenerateTokenParams{
 		UserID:   user.ID,
-		Duration: 3 * 24 * time.Hour,
+		Duration: app.config.tokens.activationTTL,
 		Scope:    db.ScopeActivation,
 	})
 	if err != nil {
@@ -250,6 +248,7 @@ func (app *application) createActivationTokenHandler(ctx *gin.Context) {
 	}
 
 	// Send an email to the user with the activation token.
+	requestID := app.contextGetRequestID(ctx)
 	app.background(func() {
 		header := mailer.EmailHeader{
 			Subject: "Activate your Greenlight account",
@@ -260,10 +259,7 @@ func (app *application) createActivationTokenHandler(ctx *gin.Context) {
 			"activationToken": tokenPlaintext,
 		}
 
-		err := app.mailer.SendEmail(header, data, "token_activation.html")
-		if err != nil {
-			app.logger.Error(err.Error())
-		}
+		app.sendEmail(requestID, header, data, "token_activation.html")
 	})
 
 	// Send a 202 Accepted response and confirmation message to the client.
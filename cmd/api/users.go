@@ -4,7 +4,6 @@ import (
 	"crypto/sha256"
 	"errors"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/katatrina/greenlight/internal/db"
@@ -75,7 +74,7 @@ func (app *application) registerUserHandler(ctx *gin.Context) {
 		Name:           *req.Name,
 		Email:          *req.Email,
 		HashedPassword: hashedPassword,
-		Permissions:    []string{movieReadPermissionCode},
+		Permissions:    []string{movieReadPermissionCode, reviewWritePermissionCode},
 	}
 
 	// Try to create a new user account with the default permissions.
@@ -94,7 +93,7 @@ func (app *application) registerUserHandler(ctx *gin.Context) {
 	// After the user record has been created, generate a new activation token for the user.
 	tokenPlaintext, _, err := app.store.GenerateToken(ctx, db.GenerateTokenParams{
 		UserID:   user.ID,
-		Duration: 3 * 24 * time.Hour,
+		Duration: app.config.tokens.activationTTL,
 		Scope:    db.ScopeActivation,
 	})
 	if err != nil {
@@ -103,6 +102,7 @@ func (app *application) registerUserHandler(ctx *gin.Context) {
 	}
 
 	// Send the user a welcome email with the activation token.
+	requestID := app.contextGetRequestID(ctx)
 	app.background(func() {
 		header := mailer.EmailHeader{
 			Subject: "Welcome to Greenlight!",
@@ -114,10 +114,7 @@ func (app *application) registerUserHandler(ctx *gin.Context) {
 			"activationToken": tokenPlaintext,
 		}
 
-		err := app.mailer.SendEmail(header, data, "user_welcome.html")
-		if err != nil {
-			app.logger.Error(err.Error())
-		}
+		app.sendEmail(requestID, header, data, "user_welcome.html")
 	})
 
 	rsp := envelope{"user": user}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/katatrina/greenlight/internal/db"
+	"github.com/katatrina/greenlight/internal/validator"
+)
+
+// At most maxWatchlistReorder movies can be moved at once in a watchlist.
+const maxWatchlistReorder = 1000
+
+// watchlistEntry is a movie of the watchlist of a user, along with when it was added.
+type watchlistEntry struct {
+	Movie    db.Movie  `json:"movie"`
+	Position int32     `json:"position"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+type listWatchlistResponse struct {
+	Metadata  db.PaginationMetadata `json:"metadata"`
+	Watchlist []watchlistEntry      `json:"watchlist"`
+}
+
+// listWatchlistHandler show the movies of the watchlist of the authenticated user, in their order.
+func (app *application) listWatchlistHandler(ctx *gin.Context) {
+	user := app.contextGetUser(ctx)
+
+	var req pageRequest
+
+	// Parse query parameters
+	err := app.readQueryParams(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate query parameters
+	violations := validatePageRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	rows, err := app.store.ListWatchlist(ctx, db.ListWatchlistParams{
+		UserID: user.ID,
+		Offset: (*req.Page - 1) * *req.PageSize,
+		Limit:  *req.PageSize,
+	})
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	var totalRecords int64
+	watchlist := make([]watchlistEntry, 0, len(rows))
+	for _, row := range rows {
+		totalRecords = row.TotalRecords
+		watchlist = append(watchlist, watchlistEntry{
+			Movie:    row.Movie,
+			Position: row.WatchlistItem.Position,
+			AddedAt:  row.WatchlistItem.AddedAt,
+		})
+	}
+
+	rsp := listWatchlistResponse{
+		Metadata:  db.CalculatePaginationMetadata(totalRecords, *req.Page, *req.PageSize),
+		Watchlist: watchlist,
+	}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+type addToWatchlistRequest struct {
+	MovieID int64 `json:"movie_id"`
+}
+
+// addToWatchlistHandler add a movie at the end of the watchlist of the authenticated user.
+func (app *application) addToWatchlistHandler(ctx *gin.Context) {
+	user := app.contextGetUser(ctx)
+
+	var req addToWatchlistRequest
+
+	// Parse the request body.
+	err := app.readJSON(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate the request body.
+	violations := validator.New()
+	if req.MovieID < 1 {
+		violations.AddError("movie_id", "must be a positive integer")
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	movie, ok := app.getWatchableMovie(ctx, req.MovieID)
+	if !ok {
+		return
+	}
+
+	item, err := app.store.AddToWatchlistTx(ctx, db.AddToWatchlistParams{
+		UserID:  user.ID,
+		MovieID: movie.ID,
+	})
+	if err != nil {
+		switch {
+		case db.ErrorCode(err) == db.UniqueViolation && db.IsContainErrorMessage(err, "watchlist_items_pkey"):
+			app.integrityConstraintViolationResponse(ctx, "the movie is already in your watchlist")
+		// The movie has been purged in the meantime.
+		case db.ErrorCode(err) == db.ForeignKeyViolation:
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	rsp := envelope{"watchlist_entry": watchlistEntry{
+		Movie:    movie,
+		Position: item.Position,
+		AddedAt:  item.AddedAt,
+	}}
+	app.writeJSON(ctx, http.StatusCreated, rsp, nil)
+}
+
+// getWatchableMovie retrieve a movie which is about to be added to the watchlist or the watched log.
+// If it doesn't exist, an error response is sent and false is returned.
+func (app *application) getWatchableMovie(ctx *gin.Context, movieID int64) (db.Movie, bool) {
+	movie, err := app.store.GetMovie(ctx, movieID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			violations := validator.New()
+			violations.AddError("movie_id", "must be an existing movie")
+			app.failedValidationResponse(ctx, violations)
+			return db.Movie{}, false
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return db.Movie{}, false
+	}
+
+	return movie, true
+}
+
+// removeFromWatchlistHandler remove a movie from the watchlist of the authenticated user.
+func (app *application) removeFromWatchlistHandler(ctx *gin.Context) {
+	user := app.contextGetUser(ctx)
+
+	movieID, err := app.readNamedIDParam(ctx, "movie_id")
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	rowsAffected, err := app.store.RemoveFromWatchlist(ctx, db.RemoveFromWatchlistParams{
+		UserID:  user.ID,
+		MovieID: movieID,
+	})
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	if rowsAffected == 0 {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	rsp := envelope{"message": "movie successfully removed from your watchlist!"}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+type reorderWatchlistRequest struct {
+	MovieIDs []int64 `json:"movie_ids"`
+}
+
+func validateReorderWatchlistRequest(req *reorderWatchlistRequest) validator.Violations {
+	violations := validator.New()
+
+	if len(req.MovieIDs) == 0 {
+		violations.AddError("movie_ids", "must contain at least 1 movie")
+		return violations
+	}
+
+	if len(req.MovieIDs) > maxWatchlistReorder {
+		violations.AddError("movie_ids", fmt.Sprintf("must not contain more than %d movies", maxWatchlistReorder))
+		return violations
+	}
+
+	seen := make(map[int64]bool, len(req.MovieIDs))
+	for _, movieID := range req.MovieIDs {
+		if movieID < 1 {
+			violations.AddError("movie_ids", "must only contain positive integers")
+			break
+		}
+
+		if seen[movieID] {
+			violations.AddError("movie_ids", "must not contain duplicate movies")
+			break
+		}
+		seen[movieID] = true
+	}
+
+	return violations
+}
+
+// reorderWatchlistHandler move some movies to the top of the watchlist of the authenticated user, in the given order.
+// The other movies of the watchlist keep their order after them, so sending all of them sets the whole order.
+func (app *application) reorderWatchlistHandler(ctx *gin.Context) {
+	user := app.contextGetUser(ctx)
+
+	var req reorderWatchlistRequest
+
+	// Parse the request body.
+	err := app.readJSON(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate the request body.
+	violations := validateReorderWatchlistRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	err = app.store.ReorderWatchlistTx(ctx, db.ReorderWatchlistTxParams{
+		UserID:   user.ID,
+		MovieIDs: req.MovieIDs,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrNotInWatchlist) {
+			violations.AddError("movie_ids", "must only contain movies of your watchlist")
+			app.failedValidationResponse(ctx, violations)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	rsp := envelope{"message": "watchlist successfully reordered!"}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+// watchedEntry is a viewing of a movie by a user.
+type watchedEntry struct {
+	ID        int64       `json:"id"`
+	Movie     db.Movie    `json:"movie"`
+	WatchedOn pgtype.Date `json:"watched_on"`
+}
+
+type listWatchedResponse struct {
+	Metadata db.PaginationMetadata `json:"metadata"`
+	Watched  []watchedEntry        `json:"watched"`
+}
+
+// listWatchedHandler show the movies watched by the authenticated user, the most recent first.
+func (app *application) listWatchedHandler(ctx *gin.Context) {
+	user := app.contextGetUser(ctx)
+
+	var req pageRequest
+
+	// Parse query parameters
+	err := app.readQueryParams(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate query parameters
+	violations := validatePageRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	rows, err := app.store.ListWatchedMovies(ctx, db.ListWatchedMoviesParams{
+		UserID: user.ID,
+		Offset: (*req.Page - 1) * *req.PageSize,
+		Limit:  *req.PageSize,
+	})
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	var totalRecords int64
+	watched := make([]watchedEntry, 0, len(rows))
+	for _, row := range rows {
+		totalRecords = row.TotalRecords
+		watched = append(watched, watchedEntry{
+			ID:        row.WatchedMovie.ID,
+			Movie:     row.Movie,
+			WatchedOn: row.WatchedMovie.WatchedOn,
+		})
+	}
+
+	rsp := listWatchedResponse{
+		Metadata: db.CalculatePaginationMetadata(totalRecords, *req.Page, *req.PageSize),
+		Watched:  watched,
+	}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
+
+type logWatchedMovieRequest struct {
+	MovieID   int64   `json:"movie_id"`
+	WatchedOn *string `json:"watched_on"`
+}
+
+// validateLogWatchedMovieRequest validates the logWatchedMovieRequest struct, and returns the date of the viewing.
+// By default, the movie has been watched today.
+func validateLogWatchedMovieRequest(req *logWatchedMovieRequest) (time.Time, validator.Violations) {
+	violations := validator.New()
+
+	if req.MovieID < 1 {
+		violations.AddError("movie_id", "must be a positive integer")
+	}
+
+	watchedOn := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.WatchedOn != nil {
+		date, err := time.Parse(time.DateOnly, *req.WatchedOn)
+		switch {
+		case err != nil:
+			violations.AddError("watched_on", "must be a date in the YYYY-MM-DD format")
+		// Leave a day of leeway for the users ahead of UTC.
+		case date.After(watchedOn.AddDate(0, 0, 1)):
+			violations.AddError("watched_on", "must not be in the future")
+		default:
+			watchedOn = date
+		}
+	}
+
+	return watchedOn, violations
+}
+
+// logWatchedMovieHandler record that the authenticated user has watched a movie, which is taken off their watchlist.
+// A movie can be logged several times, once for each viewing.
+func (app *application) logWatchedMovieHandler(ctx *gin.Context) {
+	user := app.contextGetUser(ctx)
+
+	var req logWatchedMovieRequest
+
+	// Parse the request body.
+	err := app.readJSON(ctx, &req)
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	// Validate the request body.
+	watchedOn, violations := validateLogWatchedMovieRequest(&req)
+	if !violations.Empty() {
+		app.failedValidationResponse(ctx, violations)
+		return
+	}
+
+	movie, ok := app.getWatchableMovie(ctx, req.MovieID)
+	if !ok {
+		return
+	}
+
+	watchedMovie, err := app.store.LogWatchedMovieTx(ctx, db.CreateWatchedMovieParams{
+		UserID:    user.ID,
+		MovieID:   movie.ID,
+		WatchedOn: pgtype.Date{Time: watchedOn, Valid: true},
+	})
+	if err != nil {
+		// The movie has been purged in the meantime.
+		if db.ErrorCode(err) == db.ForeignKeyViolation {
+			app.notFoundResponse(ctx)
+			return
+		}
+
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	rsp := envelope{"watched_entry": watchedEntry{
+		ID:        watchedMovie.ID,
+		Movie:     movie,
+		WatchedOn: watchedMovie.WatchedOn,
+	}}
+	app.writeJSON(ctx, http.StatusCreated, rsp, nil)
+}
+
+// deleteWatchedEntryHandler delete a viewing from the watched log of the authenticated user.
+func (app *application) deleteWatchedEntryHandler(ctx *gin.Context) {
+	user := app.contextGetUser(ctx)
+
+	entryID, err := app.readIDParam(ctx)
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	rowsAffected, err := app.store.DeleteWatchedMovie(ctx, db.DeleteWatchedMovieParams{
+		ID:     entryID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	// Other users' entries are reported as missing too.
+	if rowsAffected == 0 {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	rsp := envelope{"message": "watched entry successfully deleted!"}
+	app.writeJSON(ctx, http.StatusOK, rsp, nil)
+}
@@ -7,6 +7,8 @@ require (
 	github.com/jackc/pgx/v5 v5.6.0
 	github.com/wneessen/go-mail v0.4.2
 	golang.org/x/crypto v0.25.0
+	golang.org/x/time v0.5.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -40,5 +42,4 @@ require (
 	golang.org/x/sys v0.22.0 // indirect
 	golang.org/x/text v0.16.0 // indirect
 	google.golang.org/protobuf v1.34.2 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore stores binary objects, such as the images of the movies, under slash-separated keys.
+type BlobStore interface {
+	// Put stores the content read from r under key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get returns a reader of the object stored under key, which must be closed by the caller.
+	// It returns ErrNotFound if there is no such object.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns a URL the clients can download the object from directly,
+	// or an empty string if the object must be served by the application.
+	URL(ctx context.Context, key string) (string, error)
+}
+
+// validateKey checks that a key is made of non-empty path segments,
+// none of them being "." or "..", so that it can't escape the root of a store.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsRune(key, '\\') {
+		return ErrInvalidKey
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
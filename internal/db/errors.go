@@ -16,6 +16,7 @@ const (
 var (
 	ErrRecordNotFound       = pgx.ErrNoRows
 	ErrInvalidRuntimeFormat = errors.New("invalid runtime format")
+	ErrNotInWatchlist       = errors.New("movie not in watchlist")
 )
 
 // ErrorCode return the condition name of SQLSTATE error code returned by PostgreSQL server.
@@ -30,6 +31,15 @@ func ErrorCode(err error) string {
 	return ""
 }
 
+// IsDataError tells whether the error is caused by the data of the query, such as a constraint violation,
+// rather than by the database server.
+func IsDataError(err error) bool {
+	// The classes 22 and 23 are the data exceptions and the integrity constraint violations.
+	code := ErrorCode(err)
+
+	return strings.HasPrefix(code, "22") || strings.HasPrefix(code, "23")
+}
+
 func IsContainErrorMessage(err error, value string) bool {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"slices"
+)
+
+// replaceGenreInMovies replaces a genre by another one in all the movies, and records their new revisions.
+func replaceGenreInMovies(ctx context.Context, qtx *Queries, oldSlug, newSlug string, userID int64) error {
+	movieIDs, err := qtx.ReplaceMovieGenre(ctx, ReplaceMovieGenreParams{
+		OldSlug: oldSlug,
+		NewSlug: newSlug,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(movieIDs) == 0 {
+		return nil
+	}
+
+	return qtx.CreateMovieRevisions(ctx, CreateMovieRevisionsParams{
+		Action:   RevisionActionUpdate,
+		UserID:   userID,
+		MovieIDs: movieIDs,
+	})
+}
+
+type UpdateGenreTxParams struct {
+	UpdateGenreParams
+	// OldSlug is the slug of the genre before the update.
+	OldSlug string
+	UserID  int64
+}
+
+// UpdateGenreTx updates a genre. If its slug changes, the movies are updated with the new slug,
+// and the old one is kept as an alias of the genre.
+func (store *SQLStore) UpdateGenreTx(ctx context.Context, arg UpdateGenreTxParams) (Genre, error) {
+	var genre Genre
+
+	err := store.execTx(ctx, func(qtx *Queries) error {
+		var err error
+
+		genre, err = qtx.UpdateGenre(ctx, arg.UpdateGenreParams)
+		if err != nil {
+			return err
+		}
+
+		if genre.Slug == arg.OldSlug {
+			return nil
+		}
+
+		if !slices.Contains(genre.Aliases, arg.OldSlug) {
+			genre, err = qtx.UpdateGenre(ctx, UpdateGenreParams{
+				Aliases: append(genre.Aliases, arg.OldSlug),
+				ID:      genre.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return replaceGenreInMovies(ctx, qtx, arg.OldSlug, genre.Slug, arg.UserID)
+	})
+
+	return genre, err
+}
+
+type MergeGenresTxParams struct {
+	Source Genre
+	Target Genre
+	UserID int64
+}
+
+// MergeGenresTx merges the source genre into the target genre: the movies of the source genre get the target genre,
+// the slug and aliases of the source genre become aliases of the target genre, and the source genre is deleted.
+func (store *SQLStore) MergeGenresTx(ctx context.Context, arg MergeGenresTxParams) (Genre, error) {
+	var genre Genre
+
+	err := store.execTx(ctx, func(qtx *Queries) error {
+		err := replaceGenreInMovies(ctx, qtx, arg.Source.Slug, arg.Target.Slug, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = qtx.DeleteGenre(ctx, arg.Source.ID)
+		if err != nil {
+			return err
+		}
+
+		aliases := slices.Clone(arg.Target.Aliases)
+		for _, alias := range append([]string{arg.Source.Slug}, arg.Source.Aliases...) {
+			if !slices.Contains(aliases, alias) {
+				aliases = append(aliases, alias)
+			}
+		}
+
+		genre, err = qtx.UpdateGenre(ctx, UpdateGenreParams{
+			Aliases: aliases,
+			ID:      arg.Target.ID,
+		})
+
+		return err
+	})
+
+	return genre, err
+}
@@ -6,6 +6,9 @@ type PaginationMetadata struct {
 	FirstPage    int32 `json:"first_page,omitempty"`
 	LastPage     int32 `json:"last_page,omitempty"`
 	TotalRecords int64 `json:"total_records,omitempty"`
+	// NextCursor and PrevCursor are the opaque cursors of the next and previous pages, for keyset pagination.
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 func CalculatePaginationMetadata(totalRecords int64, page, pageSize int32) PaginationMetadata {
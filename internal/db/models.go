@@ -5,17 +5,63 @@
 package db
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Genre struct {
+	ID        int64     `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	Aliases   []string  `json:"aliases"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Movie struct {
-	ID          int64     `json:"id"`
-	Title       string    `json:"title"`
-	Runtime     Runtime   `json:"runtime"`
-	Genres      []string  `json:"genres"`
-	PublishYear int32     `json:"publish_year"`
-	Version     int32     `json:"version"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID            int64      `json:"id"`
+	Title         string     `json:"title"`
+	Runtime       Runtime    `json:"runtime"`
+	Genres        []string   `json:"genres"`
+	PublishYear   int32      `json:"publish_year"`
+	Version       int32      `json:"version"`
+	CreatedAt     time.Time  `json:"created_at"`
+	AverageRating float64    `json:"average_rating"`
+	ReviewCount   int32      `json:"review_count"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+}
+
+type MovieCredit struct {
+	ID            int64       `json:"id"`
+	MovieID       int64       `json:"movie_id"`
+	PersonID      int64       `json:"person_id"`
+	Role          string      `json:"role"`
+	CharacterName pgtype.Text `json:"character_name"`
+	BillingOrder  int32       `json:"billing_order"`
+}
+
+type MovieImage struct {
+	ID           int64       `json:"id"`
+	MovieID      int64       `json:"movie_id"`
+	Kind         string      `json:"kind"`
+	ContentType  string      `json:"content_type"`
+	Size         int64       `json:"size"`
+	Width        int32       `json:"width"`
+	Height       int32       `json:"height"`
+	BlobKey      string      `json:"blob_key"`
+	ThumbnailKey pgtype.Text `json:"thumbnail_key"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+type MovieRevision struct {
+	ID        int64           `json:"id"`
+	MovieID   int64           `json:"movie_id"`
+	Version   int32           `json:"version"`
+	Action    string          `json:"action"`
+	Snapshot  json.RawMessage `json:"snapshot"`
+	UserID    pgtype.Int8     `json:"user_id"`
+	CreatedAt time.Time       `json:"created_at"`
 }
 
 type Permission struct {
@@ -23,6 +69,26 @@ type Permission struct {
 	Code string `json:"code"`
 }
 
+type Person struct {
+	ID        int64       `json:"id"`
+	Name      string      `json:"name"`
+	BirthYear pgtype.Int4 `json:"birth_year"`
+	Biography pgtype.Text `json:"biography"`
+	Version   int32       `json:"version"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type Review struct {
+	ID        int64       `json:"id"`
+	MovieID   int64       `json:"movie_id"`
+	UserID    int64       `json:"user_id"`
+	Score     int32       `json:"score"`
+	Body      pgtype.Text `json:"body"`
+	Version   int32       `json:"version"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
 type Token struct {
 	UserID    int64     `json:"user_id"`
 	Hash      []byte    `json:"hash"`
@@ -45,3 +111,18 @@ type UserPermission struct {
 	UserID       int64 `json:"user_id"`
 	PermissionID int64 `json:"permission_id"`
 }
+
+type WatchedMovie struct {
+	ID        int64       `json:"id"`
+	UserID    int64       `json:"user_id"`
+	MovieID   int64       `json:"movie_id"`
+	WatchedOn pgtype.Date `json:"watched_on"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type WatchlistItem struct {
+	UserID   int64     `json:"user_id"`
+	MovieID  int64     `json:"movie_id"`
+	Position int32     `json:"position"`
+	AddedAt  time.Time `json:"added_at"`
+}
@@ -0,0 +1,42 @@
+package db
+
+import "context"
+
+type ReplaceMovieCreditsTxParams struct {
+	MovieID int64
+	Credits []CreateMovieCreditParams
+}
+
+// ReplaceMovieCreditsTx replaces all the credits of a movie, and returns the new ones along with their people.
+// It returns ErrRecordNotFound if the movie doesn't exist or is deleted.
+func (store *SQLStore) ReplaceMovieCreditsTx(ctx context.Context, arg ReplaceMovieCreditsTxParams) ([]ListMovieCreditsRow, error) {
+	var credits []ListMovieCreditsRow
+
+	err := store.execTx(ctx, func(qtx *Queries) error {
+		// Lock the movie first, so that the concurrent replacements of its credits happen one after another.
+		_, err := qtx.LockMovie(ctx, arg.MovieID)
+		if err != nil {
+			return err
+		}
+
+		err = qtx.DeleteMovieCredits(ctx, arg.MovieID)
+		if err != nil {
+			return err
+		}
+
+		for _, credit := range arg.Credits {
+			credit.MovieID = arg.MovieID
+
+			_, err = qtx.CreateMovieCredit(ctx, credit)
+			if err != nil {
+				return err
+			}
+		}
+
+		credits, err = qtx.ListMovieCredits(ctx, arg.MovieID)
+
+		return err
+	})
+
+	return credits, err
+}
@@ -0,0 +1,508 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// The actions recorded in the revisions of the movies.
+const (
+	RevisionActionCreate  = "create"
+	RevisionActionUpdate  = "update"
+	RevisionActionDelete  = "delete"
+	RevisionActionRestore = "restore"
+	RevisionActionRevert  = "revert"
+)
+
+// MovieFilters holds the arguments of filtered_movies, the SQL function filtering the movies of the lists.
+// The queries calling it take them as their only parameters, so that their parameters are converted
+// from MovieFilters, e.g. CountMoviesWithFiltersParams(filters).
+type MovieFilters struct {
+	Title         string
+	Fuzzy         bool
+	Genres        []string
+	GenresAny     bool
+	ExcludeGenres []string
+	YearMin       pgtype.Int4
+	YearMax       pgtype.Int4
+	RuntimeMin    pgtype.Int4
+	RuntimeMax    pgtype.Int4
+	CreatedAfter  pgtype.Timestamptz
+	CreatedBefore pgtype.Timestamptz
+	PersonID      pgtype.Int8
+	UserID        int64
+	InWatchlist   pgtype.Bool
+	Watched       pgtype.Bool
+}
+
+// ListMoviesParams holds the filters, the sort and the page of a movie list.
+type ListMoviesParams struct {
+	MovieFilters
+	// SortKey is one of id, title, publishYear, runtime, rating and relevance.
+	SortKey    string
+	Descending bool
+	// The list starts after the movie with the CursorID and the value of the sort key of the cursor,
+	// in the sort order. Without a cursor, that is with a NULL CursorID, Offset movies are skipped instead.
+	CursorID    pgtype.Int8
+	CursorText  pgtype.Text
+	CursorInt   pgtype.Int4
+	CursorFloat pgtype.Float8
+	Offset      int32
+	Limit       int32
+}
+
+// ListMoviesRow is a movie of a list, along with how well its title matches the searched one.
+type ListMoviesRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// listMoviesRow is the row of any of the queries listing the movies, which all have the fields of ListMoviesRow.
+type listMoviesRow interface {
+	ListMoviesByIDRow | ListMoviesByIDDescRow |
+		ListMoviesByTitleRow | ListMoviesByTitleDescRow |
+		ListMoviesByPublishYearRow | ListMoviesByPublishYearDescRow |
+		ListMoviesByRuntimeRow | ListMoviesByRuntimeDescRow |
+		ListMoviesByRatingRow | ListMoviesByRatingDescRow |
+		ListMoviesByRelevanceRow | ListMoviesByRelevanceDescRow
+}
+
+func toListMoviesRows[Row listMoviesRow](rows []Row, err error) ([]ListMoviesRow, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ListMoviesRow, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, ListMoviesRow(row))
+	}
+
+	return items, nil
+}
+
+// startAfter sets the cursor of arg to the given movie, so that the list starts after it.
+func (arg *ListMoviesParams) startAfter(row ListMoviesRow) {
+	movie := row.Movie
+	arg.CursorID = pgtype.Int8{Int64: movie.ID, Valid: true}
+
+	switch arg.SortKey {
+	case "title":
+		arg.CursorText = pgtype.Text{String: movie.Title, Valid: true}
+	case "publishYear":
+		arg.CursorInt = pgtype.Int4{Int32: movie.PublishYear, Valid: true}
+	case "runtime":
+		arg.CursorInt = pgtype.Int4{Int32: int32(movie.Runtime), Valid: true}
+	case "rating":
+		arg.CursorFloat = pgtype.Float8{Float64: movie.AverageRating, Valid: true}
+	case "relevance":
+		arg.CursorFloat = pgtype.Float8{Float64: row.MatchScore, Valid: true}
+	}
+}
+
+// ListMovies lists the movies matching the filters of arg, sorted by its sort key and direction.
+// It calls the query of that sort key and direction, whose parameters are the same for the sort keys
+// with the same type.
+func (q *Queries) ListMovies(ctx context.Context, arg ListMoviesParams) ([]ListMoviesRow, error) {
+	switch arg.SortKey {
+	case "id":
+		p := ListMoviesByIDParams{
+			Title:         arg.Title,
+			Fuzzy:         arg.Fuzzy,
+			Genres:        arg.Genres,
+			GenresAny:     arg.GenresAny,
+			ExcludeGenres: arg.ExcludeGenres,
+			YearMin:       arg.YearMin,
+			YearMax:       arg.YearMax,
+			RuntimeMin:    arg.RuntimeMin,
+			RuntimeMax:    arg.RuntimeMax,
+			CreatedAfter:  arg.CreatedAfter,
+			CreatedBefore: arg.CreatedBefore,
+			PersonID:      arg.PersonID,
+			UserID:        arg.UserID,
+			InWatchlist:   arg.InWatchlist,
+			Watched:       arg.Watched,
+			CursorID:      arg.CursorID,
+			Offset:        arg.Offset,
+			Limit:         arg.Limit,
+		}
+
+		if arg.Descending {
+			return toListMoviesRows(q.ListMoviesByIDDesc(ctx, ListMoviesByIDDescParams(p)))
+		}
+
+		return toListMoviesRows(q.ListMoviesByID(ctx, p))
+
+	case "title":
+		p := ListMoviesByTitleParams{
+			Title:         arg.Title,
+			Fuzzy:         arg.Fuzzy,
+			Genres:        arg.Genres,
+			GenresAny:     arg.GenresAny,
+			ExcludeGenres: arg.ExcludeGenres,
+			YearMin:       arg.YearMin,
+			YearMax:       arg.YearMax,
+			RuntimeMin:    arg.RuntimeMin,
+			RuntimeMax:    arg.RuntimeMax,
+			CreatedAfter:  arg.CreatedAfter,
+			CreatedBefore: arg.CreatedBefore,
+			PersonID:      arg.PersonID,
+			UserID:        arg.UserID,
+			InWatchlist:   arg.InWatchlist,
+			Watched:       arg.Watched,
+			CursorText:    arg.CursorText,
+			CursorID:      arg.CursorID,
+			Offset:        arg.Offset,
+			Limit:         arg.Limit,
+		}
+
+		if arg.Descending {
+			return toListMoviesRows(q.ListMoviesByTitleDesc(ctx, ListMoviesByTitleDescParams(p)))
+		}
+
+		return toListMoviesRows(q.ListMoviesByTitle(ctx, p))
+
+	case "publishYear", "runtime":
+		p := ListMoviesByPublishYearParams{
+			Title:         arg.Title,
+			Fuzzy:         arg.Fuzzy,
+			Genres:        arg.Genres,
+			GenresAny:     arg.GenresAny,
+			ExcludeGenres: arg.ExcludeGenres,
+			YearMin:       arg.YearMin,
+			YearMax:       arg.YearMax,
+			RuntimeMin:    arg.RuntimeMin,
+			RuntimeMax:    arg.RuntimeMax,
+			CreatedAfter:  arg.CreatedAfter,
+			CreatedBefore: arg.CreatedBefore,
+			PersonID:      arg.PersonID,
+			UserID:        arg.UserID,
+			InWatchlist:   arg.InWatchlist,
+			Watched:       arg.Watched,
+			CursorInt:     arg.CursorInt,
+			CursorID:      arg.CursorID,
+			Offset:        arg.Offset,
+			Limit:         arg.Limit,
+		}
+
+		switch {
+		case arg.SortKey == "publishYear" && !arg.Descending:
+			return toListMoviesRows(q.ListMoviesByPublishYear(ctx, p))
+		case arg.SortKey == "publishYear" && arg.Descending:
+			return toListMoviesRows(q.ListMoviesByPublishYearDesc(ctx, ListMoviesByPublishYearDescParams(p)))
+		case arg.SortKey == "runtime" && !arg.Descending:
+			return toListMoviesRows(q.ListMoviesByRuntime(ctx, ListMoviesByRuntimeParams(p)))
+		case arg.SortKey == "runtime" && arg.Descending:
+			return toListMoviesRows(q.ListMoviesByRuntimeDesc(ctx, ListMoviesByRuntimeDescParams(p)))
+		}
+
+	case "rating", "relevance":
+		p := ListMoviesByRatingParams{
+			Title:         arg.Title,
+			Fuzzy:         arg.Fuzzy,
+			Genres:        arg.Genres,
+			GenresAny:     arg.GenresAny,
+			ExcludeGenres: arg.ExcludeGenres,
+			YearMin:       arg.YearMin,
+			YearMax:       arg.YearMax,
+			RuntimeMin:    arg.RuntimeMin,
+			RuntimeMax:    arg.RuntimeMax,
+			CreatedAfter:  arg.CreatedAfter,
+			CreatedBefore: arg.CreatedBefore,
+			PersonID:      arg.PersonID,
+			UserID:        arg.UserID,
+			InWatchlist:   arg.InWatchlist,
+			Watched:       arg.Watched,
+			CursorFloat:   arg.CursorFloat,
+			CursorID:      arg.CursorID,
+			Offset:        arg.Offset,
+			Limit:         arg.Limit,
+		}
+
+		switch {
+		case arg.SortKey == "rating" && !arg.Descending:
+			return toListMoviesRows(q.ListMoviesByRating(ctx, p))
+		case arg.SortKey == "rating" && arg.Descending:
+			return toListMoviesRows(q.ListMoviesByRatingDesc(ctx, ListMoviesByRatingDescParams(p)))
+		case arg.SortKey == "relevance" && !arg.Descending:
+			return toListMoviesRows(q.ListMoviesByRelevance(ctx, ListMoviesByRelevanceParams(p)))
+		case arg.SortKey == "relevance" && arg.Descending:
+			return toListMoviesRows(q.ListMoviesByRelevanceDesc(ctx, ListMoviesByRelevanceDescParams(p)))
+		}
+	}
+
+	return nil, fmt.Errorf("invalid sort key %q", arg.SortKey)
+}
+
+type CreateMovieTxParams struct {
+	CreateMovieParams
+	UserID int64
+}
+
+// CreateMovieTx creates a movie and records its first revision.
+func (store *SQLStore) CreateMovieTx(ctx context.Context, arg CreateMovieTxParams) (Movie, error) {
+	var movie Movie
+
+	err := store.execTx(ctx, func(qtx *Queries) error {
+		var err error
+
+		movie, err = qtx.CreateMovie(ctx, arg.CreateMovieParams)
+		if err != nil {
+			return err
+		}
+
+		return qtx.CreateMovieRevisions(ctx, CreateMovieRevisionsParams{
+			Action:   RevisionActionCreate,
+			UserID:   arg.UserID,
+			MovieIDs: []int64{movie.ID},
+		})
+	})
+
+	return movie, err
+}
+
+type UpdateMovieTxParams struct {
+	UpdateMovieParams
+	UserID int64
+	// Action is recorded in the revision, it defaults to RevisionActionUpdate.
+	Action string
+}
+
+// UpdateMovieTx updates a movie and records the new revision.
+// Like UpdateMovie, it returns ErrRecordNotFound if the version of the movie has changed.
+func (store *SQLStore) UpdateMovieTx(ctx context.Context, arg UpdateMovieTxParams) (Movie, error) {
+	var movie Movie
+
+	if arg.Action == "" {
+		arg.Action = RevisionActionUpdate
+	}
+
+	err := store.execTx(ctx, func(qtx *Queries) error {
+		var err error
+
+		movie, err = qtx.UpdateMovie(ctx, arg.UpdateMovieParams)
+		if err != nil {
+			return err
+		}
+
+		return qtx.CreateMovieRevisions(ctx, CreateMovieRevisionsParams{
+			Action:   arg.Action,
+			UserID:   arg.UserID,
+			MovieIDs: []int64{movie.ID},
+		})
+	})
+
+	return movie, err
+}
+
+type DeleteMovieTxParams struct {
+	DeleteMovieParams
+	UserID int64
+}
+
+// DeleteMovieTx moves a movie to the trash and records the new revision.
+// It returns ErrRecordNotFound if the movie doesn't exist, is already deleted, or doesn't have the given version.
+func (store *SQLStore) DeleteMovieTx(ctx context.Context, arg DeleteMovieTxParams) error {
+	return store.execTx(ctx, func(qtx *Queries) error {
+		rowsAffected, err := qtx.DeleteMovie(ctx, arg.DeleteMovieParams)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected != 1 {
+			return ErrRecordNotFound
+		}
+
+		return qtx.CreateMovieRevisions(ctx, CreateMovieRevisionsParams{
+			Action:   RevisionActionDelete,
+			UserID:   arg.UserID,
+			MovieIDs: []int64{arg.ID},
+		})
+	})
+}
+
+// RestoreMovieTx takes a movie out of the trash and records the new revision.
+func (store *SQLStore) RestoreMovieTx(ctx context.Context, movieID, userID int64) (Movie, error) {
+	var movie Movie
+
+	err := store.execTx(ctx, func(qtx *Queries) error {
+		var err error
+
+		movie, err = qtx.RestoreMovie(ctx, movieID)
+		if err != nil {
+			return err
+		}
+
+		return qtx.CreateMovieRevisions(ctx, CreateMovieRevisionsParams{
+			Action:   RevisionActionRestore,
+			UserID:   userID,
+			MovieIDs: []int64{movie.ID},
+		})
+	})
+
+	return movie, err
+}
+
+type ImportMoviesTxParams struct {
+	Movies    []CreateMoviesParams
+	BatchSize int
+	// Atomic inserts all the movies within a single transaction, rather than one transaction per batch.
+	Atomic bool
+	UserID int64
+}
+
+// ImportBatchError is returned by ImportMoviesTx when a batch of movies couldn't be inserted.
+type ImportBatchError struct {
+	// Start and End are the indexes of the first movie of the batch and of the movie after its last one.
+	Start, End int
+	// Movie is the index of the movie whose insert failed, or -1 if the batch failed as a whole.
+	Movie int
+	Err   error
+}
+
+func (e *ImportBatchError) Error() string {
+	return fmt.Sprintf("import of the movies %d to %d: %v", e.Start, e.End-1, e.Err)
+}
+
+func (e *ImportBatchError) Unwrap() error {
+	return e.Err
+}
+
+// asImportBatchError returns the error if it's an *ImportBatchError, or wraps it in an *ImportBatchError
+// of the given movies otherwise, such as when their transaction couldn't be committed.
+func asImportBatchError(err error, start, end int) error {
+	var batchErr *ImportBatchError
+	if errors.As(err, &batchErr) {
+		return err
+	}
+
+	return &ImportBatchError{Start: start, End: end, Movie: -1, Err: err}
+}
+
+// ImportMoviesTx inserts the movies in batches along with their first revisions, and returns their IDs in the same order.
+//
+// If an error occurs in a non-atomic import, the IDs of the movies inserted by the batches
+// committed so far are returned along with it. The error is always an *ImportBatchError.
+func (store *SQLStore) ImportMoviesTx(ctx context.Context, arg ImportMoviesTxParams) ([]int64, error) {
+	ids := make([]int64, 0, len(arg.Movies))
+
+	// insertBatch sends all the inserts of the batch starting at the given index in a single round trip.
+	insertBatch := func(qtx *Queries, start int) ([]int64, error) {
+		movies := arg.Movies[start:min(start+arg.BatchSize, len(arg.Movies))]
+		batchIDs := make([]int64, len(movies))
+
+		var batchErr *ImportBatchError
+		qtx.CreateMovies(ctx, movies).QueryRow(func(i int, id int64, err error) {
+			// Once an insert fails, the following ones fail too since the transaction is aborted,
+			// so we keep the first error.
+			if err != nil {
+				if batchErr == nil {
+					batchErr = &ImportBatchError{Start: start, End: start + len(movies), Movie: start + i, Err: err}
+				}
+				return
+			}
+
+			batchIDs[i] = id
+		})
+		if batchErr != nil {
+			return nil, batchErr
+		}
+
+		err := qtx.CreateMovieRevisions(ctx, CreateMovieRevisionsParams{
+			Action:   RevisionActionCreate,
+			UserID:   arg.UserID,
+			MovieIDs: batchIDs,
+		})
+		if err != nil {
+			return nil, &ImportBatchError{Start: start, End: start + len(movies), Movie: -1, Err: err}
+		}
+
+		return batchIDs, nil
+	}
+
+	if arg.Atomic {
+		err := store.execTx(ctx, func(qtx *Queries) error {
+			for start := 0; start < len(arg.Movies); start += arg.BatchSize {
+				batchIDs, err := insertBatch(qtx, start)
+				if err != nil {
+					return err
+				}
+
+				ids = append(ids, batchIDs...)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, asImportBatchError(err, 0, len(arg.Movies))
+		}
+
+		return ids, nil
+	}
+
+	for start := 0; start < len(arg.Movies); start += arg.BatchSize {
+		var batchIDs []int64
+
+		err := store.execTx(ctx, func(qtx *Queries) error {
+			var err error
+			batchIDs, err = insertBatch(qtx, start)
+			return err
+		})
+		if err != nil {
+			return ids, asImportBatchError(err, start, min(start+arg.BatchSize, len(arg.Movies)))
+		}
+
+		ids = append(ids, batchIDs...)
+	}
+
+	return ids, nil
+}
+
+// exportBatchSize is the number of movies read at once by ExportMovies.
+const exportBatchSize = 500
+
+// ExportMovies calls fn with each movie matching the filters of arg, in its sort order.
+//
+// It goes through the list by keyset, exportBatchSize movies at a time (the cursor, offset and limit of arg
+// are ignored), so the movies are never all loaded in memory. The batches are read in a read-only, repeatable
+// read transaction, so that they all see the same movies.
+// If fn returns an error, the export stops and returns that error.
+func (store *SQLStore) ExportMovies(ctx context.Context, arg ListMoviesParams, fn func(Movie) error) error {
+	tx, err := store.connPool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return err
+	}
+	// Nothing is written, so the transaction is always rolled back.
+	defer tx.Rollback(ctx)
+
+	qtx := store.WithTx(tx)
+
+	arg.CursorID = pgtype.Int8{}
+	arg.Offset = 0
+	arg.Limit = exportBatchSize
+
+	for {
+		rows, err := qtx.ListMovies(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := fn(row.Movie); err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < exportBatchSize {
+			return nil
+		}
+
+		arg.startAfter(rows[len(rows)-1])
+	}
+}
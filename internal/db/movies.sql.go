@@ -7,14 +7,164 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countMovieFacets = `-- name: CountMovieFacets :many
+WITH filtered AS (
+    SELECT genres, publish_year, runtime
+    FROM filtered_movies(
+        $1, $2, $3, $4, $5,
+        $6, $7, $8, $9,
+        $10, $11, $12,
+        $13, $14, $15
+    )
+)
+SELECT 'genres'::text AS facet, genre::text AS value, count(*) AS count
+FROM filtered, unnest(filtered.genres) AS genre
+GROUP BY genre
+UNION ALL
+SELECT 'decade'::text AS facet, (publish_year / 10 * 10)::text AS value, count(*) AS count
+FROM filtered
+GROUP BY publish_year / 10 * 10
+UNION ALL
+SELECT 'runtime'::text AS facet, runtime_bucket AS value, count(*) AS count
+FROM (
+    SELECT CASE
+        WHEN runtime < 90 THEN '0-89'
+        WHEN runtime < 120 THEN '90-119'
+        WHEN runtime < 150 THEN '120-149'
+        ELSE '150+'
+    END AS runtime_bucket
+    FROM filtered
+) AS buckets
+GROUP BY runtime_bucket
+ORDER BY facet, count DESC, value
+`
+
+type CountMovieFacetsParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+}
+
+type CountMovieFacetsRow struct {
+	Facet string `json:"facet"`
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// All the facets are counted over the movies matching the filters, and the callers keep the ones they need.
+// The values of the decade facet are their first year, and the values of the runtime facet are buckets of minutes.
+func (q *Queries) CountMovieFacets(ctx context.Context, arg CountMovieFacetsParams) ([]CountMovieFacetsRow, error) {
+	rows, err := q.db.Query(ctx, countMovieFacets,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountMovieFacetsRow{}
+	for rows.Next() {
+		var i CountMovieFacetsRow
+		if err := rows.Scan(
+			&i.Facet,
+			&i.Value,
+			&i.Count,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countMoviesWithFilters = `-- name: CountMoviesWithFilters :one
+SELECT count(*)
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+)
+`
+
+type CountMoviesWithFiltersParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+}
+
+func (q *Queries) CountMoviesWithFilters(ctx context.Context, arg CountMoviesWithFiltersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countMoviesWithFilters,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createMovie = `-- name: CreateMovie :one
 INSERT INTO movies ( title, publish_year, runtime, genres)
 VALUES ($1, $2, $3, $4)
-RETURNING id, title, runtime, genres, publish_year, version, created_at
+RETURNING id, title, runtime, genres, publish_year, version, created_at, average_rating, review_count, deleted_at
 `
 
 type CreateMovieParams struct {
@@ -40,17 +190,31 @@ func (q *Queries) CreateMovie(ctx context.Context, arg CreateMovieParams) (Movie
 		&i.PublishYear,
 		&i.Version,
 		&i.CreatedAt,
+		&i.AverageRating,
+		&i.ReviewCount,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const deleteMovie = `-- name: DeleteMovie :execrows
-DELETE FROM movies
-WHERE id = $1
+UPDATE movies
+SET
+    deleted_at = now(),
+    version = version + 1
+WHERE id = $1 AND deleted_at IS NULL
+AND ($2::integer IS NULL OR version = $2::integer)
 `
 
-func (q *Queries) DeleteMovie(ctx context.Context, id int64) (int64, error) {
-	result, err := q.db.Exec(ctx, deleteMovie, id)
+type DeleteMovieParams struct {
+	ID      int64       `json:"id"`
+	Version pgtype.Int4 `json:"version"`
+}
+
+// The movie is only moved to the trash, it is deleted for good by PurgeDeletedMovies.
+// If a version is given, the movie is only deleted if it still has that version.
+func (q *Queries) DeleteMovie(ctx context.Context, arg DeleteMovieParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteMovie, arg.ID, arg.Version)
 	if err != nil {
 		return 0, err
 	}
@@ -58,9 +222,9 @@ func (q *Queries) DeleteMovie(ctx context.Context, id int64) (int64, error) {
 }
 
 const getMovie = `-- name: GetMovie :one
-SELECT id, title, runtime, genres, publish_year, version, created_at
+SELECT id, title, runtime, genres, publish_year, version, created_at, average_rating, review_count, deleted_at
 FROM movies
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetMovie(ctx context.Context, id int64) (Movie, error) {
@@ -74,51 +238,124 @@ func (q *Queries) GetMovie(ctx context.Context, id int64) (Movie, error) {
 		&i.PublishYear,
 		&i.Version,
 		&i.CreatedAt,
+		&i.AverageRating,
+		&i.ReviewCount,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const listMoviesWithFilters = `-- name: ListMoviesWithFilters :many
-SELECT count(*) OVER() as total_records, movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at
+const listDeletedMovies = `-- name: ListDeletedMovies :many
+SELECT count(*) OVER() as total_records, movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at
 FROM movies
-WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
-AND (genres @> $2 OR $2 = '{}')
-ORDER BY CASE
-    WHEN NOT $3::boolean AND $4::text = 'id' THEN id
-    WHEN NOT $3::boolean AND $4::text = 'publishYear' THEN publish_year
-    WHEN NOT $3::boolean AND $4::text = 'runtime' THEN runtime
-END ASC, CASE
-    WHEN $3::boolean AND $4::text = 'id' THEN id
-    WHEN $3::boolean AND $4::text = 'publishYear' THEN publish_year
-    WHEN $3::boolean AND $4::text = 'runtime' THEN runtime
-END  DESC, CASE
-    WHEN NOT $3::boolean AND $4::text = 'title' THEN title
-END ASC, CASE
-    WHEN $3::boolean AND $4::text = 'title' THEN title
-END DESC, id ASC
-LIMIT $6 OFFSET $5
-`
-
-type ListMoviesWithFiltersParams struct {
-	Title   string   `json:"title"`
-	Genres  []string `json:"genres"`
-	Reverse bool     `json:"reverse"`
-	OrderBy string   `json:"order_by"`
-	Offset  int32    `json:"offset"`
-	Limit   int32    `json:"limit"`
-}
-
-type ListMoviesWithFiltersRow struct {
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC
+LIMIT $2 OFFSET $1
+`
+
+type ListDeletedMoviesParams struct {
+	Offset int32 `json:"offset"`
+	Limit  int32 `json:"limit"`
+}
+
+type ListDeletedMoviesRow struct {
 	TotalRecords int64 `json:"total_records"`
 	Movie        Movie `json:"movie"`
 }
 
-func (q *Queries) ListMoviesWithFilters(ctx context.Context, arg ListMoviesWithFiltersParams) ([]ListMoviesWithFiltersRow, error) {
-	rows, err := q.db.Query(ctx, listMoviesWithFilters,
+func (q *Queries) ListDeletedMovies(ctx context.Context, arg ListDeletedMoviesParams) ([]ListDeletedMoviesRow, error) {
+	rows, err := q.db.Query(ctx, listDeletedMovies, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDeletedMoviesRow{}
+	for rows.Next() {
+		var i ListDeletedMoviesRow
+		if err := rows.Scan(
+			&i.TotalRecords,
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByID = `-- name: ListMoviesByID :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, movie_match_score($1, $2, movies.title) AS match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies
+WHERE movies.id > coalesce($16::bigint, 0)
+ORDER BY movies.id
+LIMIT $18 OFFSET $17
+`
+
+type ListMoviesByIDParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByIDRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// The movies of the lists are paginated by keyset: only the movies after the one the cursor points at,
+// in the sort order, are listed. Each sort key and direction has its own query, so that their conditions
+// and orders can use the indexes of (key, id). The id is used as a tie-breaker, since the keys may not be unique.
+// Without a cursor, the bounds are before all the movies, and the page is given by the offset instead.
+func (q *Queries) ListMoviesByID(ctx context.Context, arg ListMoviesByIDParams) ([]ListMoviesByIDRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByID,
 		arg.Title,
+		arg.Fuzzy,
 		arg.Genres,
-		arg.Reverse,
-		arg.OrderBy,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorID,
 		arg.Offset,
 		arg.Limit,
 	)
@@ -126,11 +363,380 @@ func (q *Queries) ListMoviesWithFilters(ctx context.Context, arg ListMoviesWithF
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListMoviesWithFiltersRow{}
+	items := []ListMoviesByIDRow{}
 	for rows.Next() {
-		var i ListMoviesWithFiltersRow
+		var i ListMoviesByIDRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByIDDesc = `-- name: ListMoviesByIDDesc :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, movie_match_score($1, $2, movies.title) AS match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies
+WHERE movies.id < coalesce($16::bigint, 9223372036854775807)
+ORDER BY movies.id DESC
+LIMIT $18 OFFSET $17
+`
+
+type ListMoviesByIDDescParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByIDDescRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// Like ListMoviesByID, in the descending order of the id.
+func (q *Queries) ListMoviesByIDDesc(ctx context.Context, arg ListMoviesByIDDescParams) ([]ListMoviesByIDDescRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByIDDesc,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMoviesByIDDescRow{}
+	for rows.Next() {
+		var i ListMoviesByIDDescRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByPublishYear = `-- name: ListMoviesByPublishYear :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, movie_match_score($1, $2, movies.title) AS match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies
+WHERE (movies.publish_year, movies.id) > (coalesce($16::integer, 0), coalesce($17::bigint, 0))
+ORDER BY movies.publish_year, movies.id
+LIMIT $19 OFFSET $18
+`
+
+type ListMoviesByPublishYearParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorInt     pgtype.Int4        `json:"cursor_int"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByPublishYearRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// Like ListMoviesByID, in the order of the publish year then the id.
+func (q *Queries) ListMoviesByPublishYear(ctx context.Context, arg ListMoviesByPublishYearParams) ([]ListMoviesByPublishYearRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByPublishYear,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorInt,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMoviesByPublishYearRow{}
+	for rows.Next() {
+		var i ListMoviesByPublishYearRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByPublishYearDesc = `-- name: ListMoviesByPublishYearDesc :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, movie_match_score($1, $2, movies.title) AS match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies
+WHERE (movies.publish_year, movies.id) < (coalesce($16::integer, 2147483647), coalesce($17::bigint, 9223372036854775807))
+ORDER BY movies.publish_year DESC, movies.id DESC
+LIMIT $19 OFFSET $18
+`
+
+type ListMoviesByPublishYearDescParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorInt     pgtype.Int4        `json:"cursor_int"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByPublishYearDescRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// Like ListMoviesByID, in the descending order of the publish year then the id.
+func (q *Queries) ListMoviesByPublishYearDesc(ctx context.Context, arg ListMoviesByPublishYearDescParams) ([]ListMoviesByPublishYearDescRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByPublishYearDesc,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorInt,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMoviesByPublishYearDescRow{}
+	for rows.Next() {
+		var i ListMoviesByPublishYearDescRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByRating = `-- name: ListMoviesByRating :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, movie_match_score($1, $2, movies.title) AS match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies
+WHERE (movies.average_rating, movies.id) > (coalesce($16::float8, '-Infinity'), coalesce($17::bigint, 0))
+ORDER BY movies.average_rating, movies.id
+LIMIT $19 OFFSET $18
+`
+
+type ListMoviesByRatingParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorFloat   pgtype.Float8      `json:"cursor_float"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByRatingRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// Like ListMoviesByID, in the order of the rating then the id.
+func (q *Queries) ListMoviesByRating(ctx context.Context, arg ListMoviesByRatingParams) ([]ListMoviesByRatingRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByRating,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorFloat,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMoviesByRatingRow{}
+	for rows.Next() {
+		var i ListMoviesByRatingRow
 		if err := rows.Scan(
-			&i.TotalRecords,
 			&i.Movie.ID,
 			&i.Movie.Title,
 			&i.Movie.Runtime,
@@ -138,6 +744,10 @@ func (q *Queries) ListMoviesWithFilters(ctx context.Context, arg ListMoviesWithF
 			&i.Movie.PublishYear,
 			&i.Movie.Version,
 			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
 		); err != nil {
 			return nil, err
 		}
@@ -149,6 +759,733 @@ func (q *Queries) ListMoviesWithFilters(ctx context.Context, arg ListMoviesWithF
 	return items, nil
 }
 
+const listMoviesByRatingDesc = `-- name: ListMoviesByRatingDesc :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, movie_match_score($1, $2, movies.title) AS match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies
+WHERE (movies.average_rating, movies.id) < (coalesce($16::float8, 'Infinity'), coalesce($17::bigint, 9223372036854775807))
+ORDER BY movies.average_rating DESC, movies.id DESC
+LIMIT $19 OFFSET $18
+`
+
+type ListMoviesByRatingDescParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorFloat   pgtype.Float8      `json:"cursor_float"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByRatingDescRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// Like ListMoviesByID, in the descending order of the rating then the id.
+func (q *Queries) ListMoviesByRatingDesc(ctx context.Context, arg ListMoviesByRatingDescParams) ([]ListMoviesByRatingDescRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByRatingDesc,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorFloat,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMoviesByRatingDescRow{}
+	for rows.Next() {
+		var i ListMoviesByRatingDescRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByRelevance = `-- name: ListMoviesByRelevance :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, search.match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies, LATERAL (
+    SELECT movie_match_score($1, $2, movies.title) AS match_score
+) AS search
+WHERE (search.match_score, movies.id) > (coalesce($16::float8, '-Infinity'), coalesce($17::bigint, 0))
+ORDER BY search.match_score, movies.id
+LIMIT $19 OFFSET $18
+`
+
+type ListMoviesByRelevanceParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorFloat   pgtype.Float8      `json:"cursor_float"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByRelevanceRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// Like ListMoviesByID, in the order of the match score then the id.
+func (q *Queries) ListMoviesByRelevance(ctx context.Context, arg ListMoviesByRelevanceParams) ([]ListMoviesByRelevanceRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByRelevance,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorFloat,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMoviesByRelevanceRow{}
+	for rows.Next() {
+		var i ListMoviesByRelevanceRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByRelevanceDesc = `-- name: ListMoviesByRelevanceDesc :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, search.match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies, LATERAL (
+    SELECT movie_match_score($1, $2, movies.title) AS match_score
+) AS search
+WHERE (search.match_score, movies.id) < (coalesce($16::float8, 'Infinity'), coalesce($17::bigint, 9223372036854775807))
+ORDER BY search.match_score DESC, movies.id DESC
+LIMIT $19 OFFSET $18
+`
+
+type ListMoviesByRelevanceDescParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorFloat   pgtype.Float8      `json:"cursor_float"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByRelevanceDescRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// Like ListMoviesByID, in the descending order of the match score then the id.
+func (q *Queries) ListMoviesByRelevanceDesc(ctx context.Context, arg ListMoviesByRelevanceDescParams) ([]ListMoviesByRelevanceDescRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByRelevanceDesc,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorFloat,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMoviesByRelevanceDescRow{}
+	for rows.Next() {
+		var i ListMoviesByRelevanceDescRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByRuntime = `-- name: ListMoviesByRuntime :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, movie_match_score($1, $2, movies.title) AS match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies
+WHERE (movies.runtime, movies.id) > (coalesce($16::integer, 0), coalesce($17::bigint, 0))
+ORDER BY movies.runtime, movies.id
+LIMIT $19 OFFSET $18
+`
+
+type ListMoviesByRuntimeParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorInt     pgtype.Int4        `json:"cursor_int"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByRuntimeRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// Like ListMoviesByID, in the order of the runtime then the id.
+func (q *Queries) ListMoviesByRuntime(ctx context.Context, arg ListMoviesByRuntimeParams) ([]ListMoviesByRuntimeRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByRuntime,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorInt,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMoviesByRuntimeRow{}
+	for rows.Next() {
+		var i ListMoviesByRuntimeRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByRuntimeDesc = `-- name: ListMoviesByRuntimeDesc :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, movie_match_score($1, $2, movies.title) AS match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies
+WHERE (movies.runtime, movies.id) < (coalesce($16::integer, 2147483647), coalesce($17::bigint, 9223372036854775807))
+ORDER BY movies.runtime DESC, movies.id DESC
+LIMIT $19 OFFSET $18
+`
+
+type ListMoviesByRuntimeDescParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorInt     pgtype.Int4        `json:"cursor_int"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByRuntimeDescRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// Like ListMoviesByID, in the descending order of the runtime then the id.
+func (q *Queries) ListMoviesByRuntimeDesc(ctx context.Context, arg ListMoviesByRuntimeDescParams) ([]ListMoviesByRuntimeDescRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByRuntimeDesc,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorInt,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMoviesByRuntimeDescRow{}
+	for rows.Next() {
+		var i ListMoviesByRuntimeDescRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByTitle = `-- name: ListMoviesByTitle :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, movie_match_score($1, $2, movies.title) AS match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies
+WHERE (movies.title, movies.id) > (coalesce($16::text, ''), coalesce($17::bigint, 0))
+ORDER BY movies.title, movies.id
+LIMIT $19 OFFSET $18
+`
+
+type ListMoviesByTitleParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorText    pgtype.Text        `json:"cursor_text"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByTitleRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// Like ListMoviesByID, in the order of the title then the id.
+func (q *Queries) ListMoviesByTitle(ctx context.Context, arg ListMoviesByTitleParams) ([]ListMoviesByTitleRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByTitle,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorText,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMoviesByTitleRow{}
+	for rows.Next() {
+		var i ListMoviesByTitleRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByTitleDesc = `-- name: ListMoviesByTitleDesc :many
+SELECT movies.id, movies.title, movies.runtime, movies.genres, movies.publish_year, movies.version, movies.created_at, movies.average_rating, movies.review_count, movies.deleted_at, movie_match_score($1, $2, movies.title) AS match_score
+FROM filtered_movies(
+    $1, $2, $3, $4, $5,
+    $6, $7, $8, $9,
+    $10, $11, $12,
+    $13, $14, $15
+) AS movies
+WHERE (movies.title, movies.id) < (coalesce($16::text, (SELECT max(title) FROM movies)), coalesce($17::bigint, 9223372036854775807))
+ORDER BY movies.title DESC, movies.id DESC
+LIMIT $19 OFFSET $18
+`
+
+type ListMoviesByTitleDescParams struct {
+	Title         string             `json:"title"`
+	Fuzzy         bool               `json:"fuzzy"`
+	Genres        []string           `json:"genres"`
+	GenresAny     bool               `json:"genres_any"`
+	ExcludeGenres []string           `json:"exclude_genres"`
+	YearMin       pgtype.Int4        `json:"year_min"`
+	YearMax       pgtype.Int4        `json:"year_max"`
+	RuntimeMin    pgtype.Int4        `json:"runtime_min"`
+	RuntimeMax    pgtype.Int4        `json:"runtime_max"`
+	CreatedAfter  pgtype.Timestamptz `json:"created_after"`
+	CreatedBefore pgtype.Timestamptz `json:"created_before"`
+	PersonID      pgtype.Int8        `json:"person_id"`
+	UserID        int64              `json:"user_id"`
+	InWatchlist   pgtype.Bool        `json:"in_watchlist"`
+	Watched       pgtype.Bool        `json:"watched"`
+	CursorText    pgtype.Text        `json:"cursor_text"`
+	CursorID      pgtype.Int8        `json:"cursor_id"`
+	Offset        int32              `json:"offset"`
+	Limit         int32              `json:"limit"`
+}
+
+type ListMoviesByTitleDescRow struct {
+	Movie      Movie   `json:"movie"`
+	MatchScore float64 `json:"match_score"`
+}
+
+// Like ListMoviesByID, in the descending order of the title then the id.
+// Without a cursor, the bound is the greatest title, since there is no greatest text.
+func (q *Queries) ListMoviesByTitleDesc(ctx context.Context, arg ListMoviesByTitleDescParams) ([]ListMoviesByTitleDescRow, error) {
+	rows, err := q.db.Query(ctx, listMoviesByTitleDesc,
+		arg.Title,
+		arg.Fuzzy,
+		arg.Genres,
+		arg.GenresAny,
+		arg.ExcludeGenres,
+		arg.YearMin,
+		arg.YearMax,
+		arg.RuntimeMin,
+		arg.RuntimeMax,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PersonID,
+		arg.UserID,
+		arg.InWatchlist,
+		arg.Watched,
+		arg.CursorText,
+		arg.CursorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMoviesByTitleDescRow{}
+	for rows.Next() {
+		var i ListMoviesByTitleDescRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Runtime,
+			&i.Movie.Genres,
+			&i.Movie.PublishYear,
+			&i.Movie.Version,
+			&i.Movie.CreatedAt,
+			&i.Movie.AverageRating,
+			&i.Movie.ReviewCount,
+			&i.Movie.DeletedAt,
+			&i.MatchScore,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedMovies = `-- name: PurgeDeletedMovies :many
+WITH purged AS (
+    DELETE FROM movies
+    WHERE deleted_at < $1
+    RETURNING id
+)
+SELECT purged.id, ARRAY(
+    SELECT image_key
+    FROM movie_images, unnest(ARRAY[movie_images.blob_key, movie_images.thumbnail_key]) AS image_key
+    WHERE movie_images.movie_id = purged.id AND image_key IS NOT NULL
+)::text[] AS blob_keys
+FROM purged
+`
+
+type PurgeDeletedMoviesRow struct {
+	ID       int64    `json:"id"`
+	BlobKeys []string `json:"blob_keys"`
+}
+
+// The images of the movies are deleted along with them, so the keys of their blobs are returned,
+// for the blobs to be deleted too. They are read from the snapshot before the deletion.
+func (q *Queries) PurgeDeletedMovies(ctx context.Context, deletedBefore time.Time) ([]PurgeDeletedMoviesRow, error) {
+	rows, err := q.db.Query(ctx, purgeDeletedMovies, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PurgeDeletedMoviesRow{}
+	for rows.Next() {
+		var i PurgeDeletedMoviesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BlobKeys,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreMovie = `-- name: RestoreMovie :one
+UPDATE movies
+SET
+    deleted_at = NULL,
+    version = version + 1
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, title, runtime, genres, publish_year, version, created_at, average_rating, review_count, deleted_at
+`
+
+func (q *Queries) RestoreMovie(ctx context.Context, id int64) (Movie, error) {
+	row := q.db.QueryRow(ctx, restoreMovie, id)
+	var i Movie
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Runtime,
+		&i.Genres,
+		&i.PublishYear,
+		&i.Version,
+		&i.CreatedAt,
+		&i.AverageRating,
+		&i.ReviewCount,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const updateMovie = `-- name: UpdateMovie :one
 UPDATE movies
 SET
@@ -157,8 +1494,8 @@ SET
     runtime = coalesce($3::int, runtime),
     genres = coalesce($4, genres),
     version = version + 1
-WHERE id = $5 AND version = $6
-RETURNING id, title, runtime, genres, publish_year, version, created_at
+WHERE id = $5 AND version = $6 AND deleted_at IS NULL
+RETURNING id, title, runtime, genres, publish_year, version, created_at, average_rating, review_count, deleted_at
 `
 
 type UpdateMovieParams struct {
@@ -188,6 +1525,9 @@ func (q *Queries) UpdateMovie(ctx context.Context, arg UpdateMovieParams) (Movie
 		&i.PublishYear,
 		&i.Version,
 		&i.CreatedAt,
+		&i.AverageRating,
+		&i.ReviewCount,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -6,22 +6,82 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
 	ActivateUser(ctx context.Context, arg ActivateUserParams) (User, error)
 	AddPermissionsForUser(ctx context.Context, arg AddPermissionsForUserParams) error
+	AddToWatchlist(ctx context.Context, arg AddToWatchlistParams) (WatchlistItem, error)
+	CountMovieFacets(ctx context.Context, arg CountMovieFacetsParams) ([]CountMovieFacetsRow, error)
+	CountMovieRevisions(ctx context.Context, movieIDs []int64) ([]CountMovieRevisionsRow, error)
+	CountMoviesWithFilters(ctx context.Context, arg CountMoviesWithFiltersParams) (int64, error)
+	CreateGenre(ctx context.Context, arg CreateGenreParams) (Genre, error)
 	CreateMovie(ctx context.Context, arg CreateMovieParams) (Movie, error)
+	CreateMovieCredit(ctx context.Context, arg CreateMovieCreditParams) (MovieCredit, error)
+	CreateMovieImage(ctx context.Context, arg CreateMovieImageParams) (MovieImage, error)
+	CreateMovieRevisions(ctx context.Context, arg CreateMovieRevisionsParams) error
+	CreateMovies(ctx context.Context, arg []CreateMoviesParams) *CreateMoviesBatchResults
+	CreatePerson(ctx context.Context, arg CreatePersonParams) (Person, error)
+	CreateReview(ctx context.Context, arg CreateReviewParams) (Review, error)
 	CreateToken(ctx context.Context, arg CreateTokenParams) (Token, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeleteMovie(ctx context.Context, id int64) (int64, error)
+	CreateWatchedMovie(ctx context.Context, arg CreateWatchedMovieParams) (WatchedMovie, error)
+	DeleteGenre(ctx context.Context, id int64) error
+	DeleteMovie(ctx context.Context, arg DeleteMovieParams) (int64, error)
+	DeleteMovieCredits(ctx context.Context, movieID int64) error
+	DeleteMovieImage(ctx context.Context, arg DeleteMovieImageParams) (MovieImage, error)
+	DeletePerson(ctx context.Context, id int64) (int64, error)
+	DeleteReview(ctx context.Context, id int64) (int64, error)
 	DeleteUserTokens(ctx context.Context, arg DeleteUserTokensParams) error
+	DeleteWatchedMovie(ctx context.Context, arg DeleteWatchedMovieParams) (int64, error)
+	GetGenre(ctx context.Context, slug string) (Genre, error)
 	GetMovie(ctx context.Context, id int64) (Movie, error)
+	GetMovieImage(ctx context.Context, arg GetMovieImageParams) (MovieImage, error)
+	GetMovieRevision(ctx context.Context, arg GetMovieRevisionParams) (MovieRevision, error)
+	GetPerson(ctx context.Context, id int64) (Person, error)
+	GetReview(ctx context.Context, id int64) (Review, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByToken(ctx context.Context, arg GetUserByTokenParams) (User, error)
 	GetUserPermissions(ctx context.Context, id int64) ([]string, error)
-	ListMoviesWithFilters(ctx context.Context, arg ListMoviesWithFiltersParams) ([]ListMoviesWithFiltersRow, error)
+	ListDeletedMovies(ctx context.Context, arg ListDeletedMoviesParams) ([]ListDeletedMoviesRow, error)
+	ListGenres(ctx context.Context) ([]Genre, error)
+	ListGenresWithCounts(ctx context.Context) ([]ListGenresWithCountsRow, error)
+	ListLatestMovieReviews(ctx context.Context, arg ListLatestMovieReviewsParams) ([]Review, error)
+	ListMovieCredits(ctx context.Context, movieID int64) ([]ListMovieCreditsRow, error)
+	ListMovieImages(ctx context.Context, movieID int64) ([]MovieImage, error)
+	ListMovieReviews(ctx context.Context, arg ListMovieReviewsParams) ([]ListMovieReviewsRow, error)
+	ListMovieRevisions(ctx context.Context, arg ListMovieRevisionsParams) ([]ListMovieRevisionsRow, error)
+	ListMoviesByID(ctx context.Context, arg ListMoviesByIDParams) ([]ListMoviesByIDRow, error)
+	ListMoviesByIDDesc(ctx context.Context, arg ListMoviesByIDDescParams) ([]ListMoviesByIDDescRow, error)
+	ListMoviesByPublishYear(ctx context.Context, arg ListMoviesByPublishYearParams) ([]ListMoviesByPublishYearRow, error)
+	ListMoviesByPublishYearDesc(ctx context.Context, arg ListMoviesByPublishYearDescParams) ([]ListMoviesByPublishYearDescRow, error)
+	ListMoviesByRating(ctx context.Context, arg ListMoviesByRatingParams) ([]ListMoviesByRatingRow, error)
+	ListMoviesByRatingDesc(ctx context.Context, arg ListMoviesByRatingDescParams) ([]ListMoviesByRatingDescRow, error)
+	ListMoviesByRelevance(ctx context.Context, arg ListMoviesByRelevanceParams) ([]ListMoviesByRelevanceRow, error)
+	ListMoviesByRelevanceDesc(ctx context.Context, arg ListMoviesByRelevanceDescParams) ([]ListMoviesByRelevanceDescRow, error)
+	ListMoviesByRuntime(ctx context.Context, arg ListMoviesByRuntimeParams) ([]ListMoviesByRuntimeRow, error)
+	ListMoviesByRuntimeDesc(ctx context.Context, arg ListMoviesByRuntimeDescParams) ([]ListMoviesByRuntimeDescRow, error)
+	ListMoviesByTitle(ctx context.Context, arg ListMoviesByTitleParams) ([]ListMoviesByTitleRow, error)
+	ListMoviesByTitleDesc(ctx context.Context, arg ListMoviesByTitleDescParams) ([]ListMoviesByTitleDescRow, error)
+	ListPeople(ctx context.Context, arg ListPeopleParams) ([]ListPeopleRow, error)
+	ListPersonFilmography(ctx context.Context, arg ListPersonFilmographyParams) ([]ListPersonFilmographyRow, error)
+	ListWatchedMovies(ctx context.Context, arg ListWatchedMoviesParams) ([]ListWatchedMoviesRow, error)
+	ListWatchlist(ctx context.Context, arg ListWatchlistParams) ([]ListWatchlistRow, error)
+	LockMovie(ctx context.Context, id int64) (int64, error)
+	LockWatchlist(ctx context.Context, userID int64) ([]int64, error)
+	LockWatchlistOwner(ctx context.Context, id int64) error
+	PurgeDeletedMovies(ctx context.Context, deletedBefore time.Time) ([]PurgeDeletedMoviesRow, error)
+	RemoveFromWatchlist(ctx context.Context, arg RemoveFromWatchlistParams) (int64, error)
+	ReplaceMovieGenre(ctx context.Context, arg ReplaceMovieGenreParams) ([]int64, error)
+	RestoreMovie(ctx context.Context, id int64) (Movie, error)
+	SetMovieImageThumbnail(ctx context.Context, arg SetMovieImageThumbnailParams) (int64, error)
+	SetWatchlistPositions(ctx context.Context, arg SetWatchlistPositionsParams) error
+	UpdateGenre(ctx context.Context, arg UpdateGenreParams) (Genre, error)
 	UpdateMovie(ctx context.Context, arg UpdateMovieParams) (Movie, error)
+	UpdateMovieRatings(ctx context.Context, id int64) error
+	UpdatePerson(ctx context.Context, arg UpdatePersonParams) (Person, error)
+	UpdateReview(ctx context.Context, arg UpdateReviewParams) (Review, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
 }
 
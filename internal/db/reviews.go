@@ -0,0 +1,76 @@
+package db
+
+import "context"
+
+func (store *SQLStore) CreateReviewTx(ctx context.Context, arg CreateReviewParams) (Review, error) {
+	var review Review
+
+	err := store.execTx(ctx, func(qtx *Queries) error {
+		var err error
+
+		// Lock the movie first, so that the concurrent reviews of the same movie
+		// recalculate its rating one after another.
+		_, err = qtx.LockMovie(ctx, arg.MovieID)
+		if err != nil {
+			return err
+		}
+
+		review, err = qtx.CreateReview(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return qtx.UpdateMovieRatings(ctx, arg.MovieID)
+	})
+
+	return review, err
+}
+
+func (store *SQLStore) UpdateReviewTx(ctx context.Context, arg UpdateReviewParams) (Review, error) {
+	var review Review
+
+	err := store.execTx(ctx, func(qtx *Queries) error {
+		var err error
+
+		existingReview, err := qtx.GetReview(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = qtx.LockMovie(ctx, existingReview.MovieID)
+		if err != nil {
+			return err
+		}
+
+		review, err = qtx.UpdateReview(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return qtx.UpdateMovieRatings(ctx, review.MovieID)
+	})
+
+	return review, err
+}
+
+// DeleteReviewTx deletes the review and recalculates the rating of its movie.
+// It returns ErrRecordNotFound if the review doesn't exist.
+func (store *SQLStore) DeleteReviewTx(ctx context.Context, review Review) error {
+	return store.execTx(ctx, func(qtx *Queries) error {
+		_, err := qtx.LockMovie(ctx, review.MovieID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := qtx.DeleteReview(ctx, review.ID)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected != 1 {
+			return ErrRecordNotFound
+		}
+
+		return qtx.UpdateMovieRatings(ctx, review.MovieID)
+	})
+}
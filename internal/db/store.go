@@ -14,6 +14,24 @@ type Store interface {
 	RegisterUserTx(ctx context.Context, arg RegisterUserTxParams) (User, error)
 	ActivateUserTx(ctx context.Context, arg ActivateUserParams) (User, error)
 	ResetUserPasswordTx(ctx context.Context, arg ResetUserPasswordTxParams) error
+	CreateMovieTx(ctx context.Context, arg CreateMovieTxParams) (Movie, error)
+	UpdateMovieTx(ctx context.Context, arg UpdateMovieTxParams) (Movie, error)
+	DeleteMovieTx(ctx context.Context, arg DeleteMovieTxParams) error
+	RestoreMovieTx(ctx context.Context, movieID, userID int64) (Movie, error)
+	ImportMoviesTx(ctx context.Context, arg ImportMoviesTxParams) ([]int64, error)
+	ListMovies(ctx context.Context, arg ListMoviesParams) ([]ListMoviesRow, error)
+	ExportMovies(ctx context.Context, arg ListMoviesParams, fn func(Movie) error) error
+	UpdateGenreTx(ctx context.Context, arg UpdateGenreTxParams) (Genre, error)
+	MergeGenresTx(ctx context.Context, arg MergeGenresTxParams) (Genre, error)
+	ReplaceMovieCreditsTx(ctx context.Context, arg ReplaceMovieCreditsTxParams) ([]ListMovieCreditsRow, error)
+	AddToWatchlistTx(ctx context.Context, arg AddToWatchlistParams) (WatchlistItem, error)
+	ReorderWatchlistTx(ctx context.Context, arg ReorderWatchlistTxParams) error
+	LogWatchedMovieTx(ctx context.Context, arg CreateWatchedMovieParams) (WatchedMovie, error)
+	CreateReviewTx(ctx context.Context, arg CreateReviewParams) (Review, error)
+	UpdateReviewTx(ctx context.Context, arg UpdateReviewParams) (Review, error)
+	DeleteReviewTx(ctx context.Context, review Review) error
+	Stat() *pgxpool.Stat
+	Ping(ctx context.Context) error
 }
 
 // SQLStore is the implementation of the Store interface.
@@ -30,6 +48,16 @@ func NewStore(connPool *pgxpool.Pool) Store {
 	}
 }
 
+// Stat returns the statistics of the underlying connection pool.
+func (store *SQLStore) Stat() *pgxpool.Stat {
+	return store.connPool.Stat()
+}
+
+// Ping acquires a connection from the pool and checks that the database is reachable.
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.connPool.Ping(ctx)
+}
+
 // execTx executes a series of queries provided by fn param within a database transaction.
 func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
 	// Begin a new transaction.
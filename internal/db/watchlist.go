@@ -0,0 +1,105 @@
+package db
+
+import "context"
+
+// AddToWatchlistTx adds a movie at the end of the watchlist of a user.
+// The watchlist is locked first, so that the movies added concurrently get different positions.
+func (store *SQLStore) AddToWatchlistTx(ctx context.Context, arg AddToWatchlistParams) (WatchlistItem, error) {
+	var item WatchlistItem
+
+	err := store.execTx(ctx, func(qtx *Queries) error {
+		err := qtx.LockWatchlistOwner(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		item, err = qtx.AddToWatchlist(ctx, arg)
+		return err
+	})
+
+	return item, err
+}
+
+type ReorderWatchlistTxParams struct {
+	UserID   int64
+	MovieIDs []int64
+}
+
+// ReorderWatchlistTx moves the given movies to the top of the watchlist of a user, in the given order.
+// The other movies of the watchlist follow them, in their current order.
+// It returns ErrNotInWatchlist if one of the movies isn't in the watchlist.
+func (store *SQLStore) ReorderWatchlistTx(ctx context.Context, arg ReorderWatchlistTxParams) error {
+	return store.execTx(ctx, func(qtx *Queries) error {
+		// Lock the watchlist first, so that the concurrent changes to it happen one after another.
+		err := qtx.LockWatchlistOwner(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		movieIDs, err := qtx.LockWatchlist(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		order, err := reorderMovieIDs(movieIDs, arg.MovieIDs)
+		if err != nil {
+			return err
+		}
+
+		return qtx.SetWatchlistPositions(ctx, SetWatchlistPositionsParams{
+			MovieIDs: order,
+			UserID:   arg.UserID,
+		})
+	})
+}
+
+// reorderMovieIDs returns the movieIDs of a watchlist with the moved ones first, in their given order,
+// followed by the other ones in their current order.
+// It returns ErrNotInWatchlist if one of the moved movies isn't in the watchlist.
+func reorderMovieIDs(movieIDs, moved []int64) ([]int64, error) {
+	inWatchlist := make(map[int64]bool, len(movieIDs))
+	for _, movieID := range movieIDs {
+		inWatchlist[movieID] = true
+	}
+
+	isMoved := make(map[int64]bool, len(moved))
+	for _, movieID := range moved {
+		if !inWatchlist[movieID] {
+			return nil, ErrNotInWatchlist
+		}
+		isMoved[movieID] = true
+	}
+
+	order := make([]int64, 0, len(movieIDs))
+	order = append(order, moved...)
+	for _, movieID := range movieIDs {
+		if !isMoved[movieID] {
+			order = append(order, movieID)
+		}
+	}
+
+	return order, nil
+}
+
+// LogWatchedMovieTx records that a user has watched a movie, which is taken off their watchlist.
+func (store *SQLStore) LogWatchedMovieTx(ctx context.Context, arg CreateWatchedMovieParams) (WatchedMovie, error) {
+	var watchedMovie WatchedMovie
+
+	err := store.execTx(ctx, func(qtx *Queries) error {
+		var err error
+
+		watchedMovie, err = qtx.CreateWatchedMovie(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		_, err = qtx.RemoveFromWatchlist(ctx, RemoveFromWatchlistParams{
+			UserID:  arg.UserID,
+			MovieID: arg.MovieID,
+		})
+
+		return err
+	})
+
+	return watchedMovie, err
+}
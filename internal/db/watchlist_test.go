@@ -0,0 +1,38 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReorderMovieIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		movieIDs []int64
+		moved    []int64
+		want     []int64
+		wantErr  error
+	}{
+		{"nothing moved", []int64{1, 2, 3}, nil, []int64{1, 2, 3}, nil},
+		{"one to the top", []int64{1, 2, 3}, []int64{3}, []int64{3, 1, 2}, nil},
+		{"several in order", []int64{1, 2, 3, 4}, []int64{4, 2}, []int64{4, 2, 1, 3}, nil},
+		{"already on top", []int64{1, 2, 3}, []int64{1, 2}, []int64{1, 2, 3}, nil},
+		{"all moved", []int64{1, 2, 3}, []int64{3, 2, 1}, []int64{3, 2, 1}, nil},
+		{"not in watchlist", []int64{1, 2, 3}, []int64{2, 4}, nil, ErrNotInWatchlist},
+		{"empty watchlist", nil, []int64{1}, nil, ErrNotInWatchlist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reorderMovieIDs(tt.movieIDs, tt.moved)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("reorderMovieIDs() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reorderMovieIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
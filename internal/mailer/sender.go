@@ -1,14 +1,10 @@
 package mailer
 
 import (
+	"context"
 	"embed"
 )
 
-const (
-	senderEmailName    = "Greenlight"
-	senderEmailAddress = "noreply@mail.accounts.greenlight.com"
-)
-
 var (
 	//go:embed "templates"
 	templateFS embed.FS
@@ -20,6 +16,8 @@ type EmailSender interface {
 		data any,
 		htmlTemplateFile string,
 	) error
+	// Ping checks that the mail transport can be dialed.
+	Ping(ctx context.Context) error
 }
 
 type EmailHeader struct {
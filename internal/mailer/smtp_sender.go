@@ -2,34 +2,58 @@ package mailer
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"html/template"
 
 	mail "github.com/wneessen/go-mail"
 )
 
-const (
-	smtpMailtrapHost = "sandbox.smtp.mailtrap.io"
-	smtpMailtrapPort = 587
-)
-
-type MailtrapSender struct {
+type SMTPSender struct {
 	client *mail.Client
+	// sender is the From address of the emails, e.g. "Greenlight <noreply@mail.accounts.greenlight.com>".
+	sender string
+	// host and options are kept to create new clients when checking the mail transport.
+	host    string
+	options []mail.Option
 }
 
-func NewMailtrapSender(username, password string) (EmailSender, error) {
-	client, err := mail.NewClient(smtpMailtrapHost, mail.WithPort(smtpMailtrapPort), mail.WithSMTPAuth(mail.SMTPAuthPlain),
-		mail.WithUsername(username), mail.WithPassword(password))
+func NewSMTPSender(host string, port int, username, password, sender string) (EmailSender, error) {
+	options := []mail.Option{mail.WithPort(port), mail.WithSMTPAuth(mail.SMTPAuthPlain),
+		mail.WithUsername(username), mail.WithPassword(password)}
+
+	client, err := mail.NewClient(host, options...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &MailtrapSender{
-		client: client,
+	return &SMTPSender{
+		client:  client,
+		sender:  sender,
+		host:    host,
+		options: options,
 	}, nil
 }
 
-func (sender *MailtrapSender) SendEmail(
+// Ping dials the SMTP server and closes the connection straight away.
+//
+// It uses a new client rather than the one sending the emails, since a client can
+// only hold a single connection at a time.
+func (sender *SMTPSender) Ping(ctx context.Context) error {
+	client, err := mail.NewClient(sender.host, sender.options...)
+	if err != nil {
+		return err
+	}
+
+	err = client.DialWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to dial mail server: %w", err)
+	}
+
+	return client.Close()
+}
+
+func (sender *SMTPSender) SendEmail(
 	header EmailHeader,
 	data any,
 	htmlTemplateFile string,
@@ -46,7 +70,7 @@ func (sender *MailtrapSender) SendEmail(
 	// Prepare email header fields
 
 	// Set "From: Greenlight <noreply@mail.accounts.greenlight.com>"
-	err = m.FromFormat(senderEmailName, senderEmailAddress)
+	err = m.From(sender.sender)
 	if err != nil {
 		return fmt.Errorf("failed to set From address %w", err)
 	}
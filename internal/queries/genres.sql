@@ -0,0 +1,48 @@
+-- name: CreateGenre :one
+INSERT INTO genres (slug, name, aliases)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetGenre :one
+SELECT *
+FROM genres
+WHERE slug = $1;
+
+-- name: ListGenres :many
+SELECT *
+FROM genres
+ORDER BY slug;
+
+-- name: ListGenresWithCounts :many
+SELECT sqlc.embed(genres), count(movies.id) AS movie_count
+FROM genres
+LEFT JOIN movies ON movies.genres @> ARRAY[genres.slug] AND movies.deleted_at IS NULL
+GROUP BY genres.id
+ORDER BY genres.name;
+
+-- name: UpdateGenre :one
+UPDATE genres
+SET
+    slug = coalesce(sqlc.narg('slug'), slug),
+    name = coalesce(sqlc.narg('name'), name),
+    aliases = coalesce(sqlc.narg('aliases'), aliases)
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: DeleteGenre :exec
+DELETE FROM genres
+WHERE id = $1;
+
+-- name: ReplaceMovieGenre :many
+-- The genre is replaced in all the movies, including the deleted ones, and the duplicates are removed.
+UPDATE movies
+SET
+    genres = ARRAY(
+        SELECT genre
+        FROM unnest(array_replace(genres, sqlc.arg('old_slug')::text, sqlc.arg('new_slug')::text)) WITH ORDINALITY AS genre
+        GROUP BY genre
+        ORDER BY min(ordinality)
+    ),
+    version = version + 1
+WHERE genres @> ARRAY[sqlc.arg('old_slug')::text]
+RETURNING id;
\ No newline at end of file
@@ -0,0 +1,25 @@
+-- name: CreateMovieCredit :one
+INSERT INTO movie_credits (movie_id, person_id, role, character_name, billing_order)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: DeleteMovieCredits :exec
+DELETE FROM movie_credits
+WHERE movie_id = $1;
+
+-- name: ListMovieCredits :many
+-- The directors come first, then the writers and the actors, each in their billing order.
+SELECT sqlc.embed(movie_credits), sqlc.embed(people)
+FROM movie_credits
+JOIN people ON people.id = movie_credits.person_id
+WHERE movie_credits.movie_id = $1
+ORDER BY array_position(ARRAY['director', 'writer', 'actor'], movie_credits.role), movie_credits.billing_order, movie_credits.id;
+
+-- name: ListPersonFilmography :many
+-- The most recent movies come first. The deleted movies are left out.
+SELECT count(*) OVER() as total_records, sqlc.embed(movies), sqlc.embed(movie_credits)
+FROM movie_credits
+JOIN movies ON movies.id = movie_credits.movie_id
+WHERE movie_credits.person_id = sqlc.arg('person_id') AND movies.deleted_at IS NULL
+ORDER BY movies.publish_year DESC, movies.id DESC, array_position(ARRAY['director', 'writer', 'actor'], movie_credits.role)
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
\ No newline at end of file
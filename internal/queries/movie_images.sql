@@ -0,0 +1,31 @@
+-- name: CreateMovieImage :one
+INSERT INTO movie_images (movie_id, kind, content_type, size, width, height, blob_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetMovieImage :one
+-- The images of the soft-deleted movies are hidden until the movies are restored.
+SELECT movie_images.*
+FROM movie_images
+JOIN movies ON movies.id = movie_images.movie_id
+WHERE movie_images.id = $1 AND movie_images.movie_id = $2 AND movies.deleted_at IS NULL;
+
+-- name: ListMovieImages :many
+SELECT *
+FROM movie_images
+WHERE movie_id = $1
+ORDER BY kind, id;
+
+-- name: SetMovieImageThumbnail :execrows
+-- No row is updated if the image has been deleted in the meantime.
+UPDATE movie_images
+SET thumbnail_key = $2
+WHERE id = $1;
+
+-- name: DeleteMovieImage :one
+-- The images of the soft-deleted movies are kept until the movies are restored or purged.
+DELETE FROM movie_images
+USING movies
+WHERE movie_images.id = $1 AND movie_images.movie_id = $2
+  AND movies.id = movie_images.movie_id AND movies.deleted_at IS NULL
+RETURNING movie_images.*;
\ No newline at end of file
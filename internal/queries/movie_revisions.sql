@@ -0,0 +1,25 @@
+-- name: CreateMovieRevisions :exec
+-- The snapshots are taken from the current state of the movies.
+INSERT INTO movie_revisions (movie_id, version, action, snapshot, user_id)
+SELECT id, version, sqlc.arg('action')::text, jsonb_build_object('title', title, 'publish_year', publish_year, 'runtime', runtime, 'genres', genres), sqlc.arg('user_id')::bigint
+FROM movies
+WHERE id = ANY(sqlc.arg('movie_ids')::bigint[]);
+
+-- name: GetMovieRevision :one
+SELECT *
+FROM movie_revisions
+WHERE movie_id = $1 AND version = $2;
+
+-- name: ListMovieRevisions :many
+-- Each revision comes with the snapshot of the previous one, so that they can be compared.
+SELECT count(*) OVER() as total_records, sqlc.embed(movie_revisions), lag(snapshot) OVER (ORDER BY version) as previous_snapshot
+FROM movie_revisions
+WHERE movie_id = sqlc.arg('movie_id')
+ORDER BY version DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountMovieRevisions :many
+SELECT movie_id, count(*) AS revision_count
+FROM movie_revisions
+WHERE movie_id = ANY(sqlc.arg('movie_ids')::bigint[])
+GROUP BY movie_id;
\ No newline at end of file
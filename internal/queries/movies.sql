@@ -3,10 +3,15 @@ INSERT INTO movies ( title, publish_year, runtime, genres)
 VALUES ($1, $2, $3, $4)
 RETURNING *;
 
+-- name: CreateMovies :batchone
+INSERT INTO movies (title, publish_year, runtime, genres)
+VALUES ($1, $2, $3, $4)
+RETURNING id;
+
 -- name: GetMovie :one
 SELECT *
 FROM movies
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: UpdateMovie :one
 UPDATE movies
@@ -16,29 +21,251 @@ SET
     runtime = coalesce(sqlc.narg('runtime')::int, runtime),
     genres = coalesce(sqlc.narg('genres'), genres),
     version = version + 1
-WHERE id = sqlc.arg('id') AND version = sqlc.arg('version')
+WHERE id = sqlc.arg('id') AND version = sqlc.arg('version') AND deleted_at IS NULL
 RETURNING *;
 
 -- name: DeleteMovie :execrows
-DELETE FROM movies
-WHERE id = $1;
+-- The movie is only moved to the trash, it is deleted for good by PurgeDeletedMovies.
+-- If a version is given, the movie is only deleted if it still has that version.
+UPDATE movies
+SET
+    deleted_at = now(),
+    version = version + 1
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL
+AND (sqlc.narg('version')::integer IS NULL OR version = sqlc.narg('version')::integer);
 
--- name: ListMoviesWithFilters :many
+-- name: RestoreMovie :one
+UPDATE movies
+SET
+    deleted_at = NULL,
+    version = version + 1
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: ListDeletedMovies :many
 SELECT count(*) OVER() as total_records, sqlc.embed(movies)
 FROM movies
-WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', sqlc.arg('title')) OR sqlc.arg('title') = '')
-AND (genres @> sqlc.arg('genres') OR sqlc.arg('genres') = '{}')
-ORDER BY CASE
-    WHEN NOT sqlc.arg('reverse')::boolean AND sqlc.arg('order_by')::text = 'id' THEN id
-    WHEN NOT sqlc.arg('reverse')::boolean AND sqlc.arg('order_by')::text = 'publishYear' THEN publish_year
-    WHEN NOT sqlc.arg('reverse')::boolean AND sqlc.arg('order_by')::text = 'runtime' THEN runtime
-END ASC, CASE
-    WHEN sqlc.arg('reverse')::boolean AND sqlc.arg('order_by')::text = 'id' THEN id
-    WHEN sqlc.arg('reverse')::boolean AND sqlc.arg('order_by')::text = 'publishYear' THEN publish_year
-    WHEN sqlc.arg('reverse')::boolean AND sqlc.arg('order_by')::text = 'runtime' THEN runtime
-END  DESC, CASE
-    WHEN NOT sqlc.arg('reverse')::boolean AND sqlc.arg('order_by')::text = 'title' THEN title
-END ASC, CASE
-    WHEN sqlc.arg('reverse')::boolean AND sqlc.arg('order_by')::text = 'title' THEN title
-END DESC, id ASC
-LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
\ No newline at end of file
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: PurgeDeletedMovies :many
+-- The images of the movies are deleted along with them, so the keys of their blobs are returned,
+-- for the blobs to be deleted too. They are read from the snapshot before the deletion.
+WITH purged AS (
+    DELETE FROM movies
+    WHERE deleted_at < sqlc.arg('deleted_before')
+    RETURNING id
+)
+SELECT purged.id, ARRAY(
+    SELECT image_key
+    FROM movie_images, unnest(ARRAY[movie_images.blob_key, movie_images.thumbnail_key]) AS image_key
+    WHERE movie_images.movie_id = purged.id AND image_key IS NOT NULL
+)::text[] AS blob_keys
+FROM purged;
+
+-- name: ListMoviesByID :many
+-- The movies of the lists are paginated by keyset: only the movies after the one the cursor points at,
+-- in the sort order, are listed. Each sort key and direction has its own query, so that their conditions
+-- and orders can use the indexes of (key, id). The id is used as a tie-breaker, since the keys may not be unique.
+-- Without a cursor, the bounds are before all the movies, and the page is given by the offset instead.
+SELECT sqlc.embed(movies), movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies
+WHERE movies.id > coalesce(sqlc.narg('cursor_id')::bigint, 0)
+ORDER BY movies.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListMoviesByIDDesc :many
+-- Like ListMoviesByID, in the descending order of the id.
+SELECT sqlc.embed(movies), movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies
+WHERE movies.id < coalesce(sqlc.narg('cursor_id')::bigint, 9223372036854775807)
+ORDER BY movies.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListMoviesByTitle :many
+-- Like ListMoviesByID, in the order of the title then the id.
+SELECT sqlc.embed(movies), movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies
+WHERE (movies.title, movies.id) > (coalesce(sqlc.narg('cursor_text')::text, ''), coalesce(sqlc.narg('cursor_id')::bigint, 0))
+ORDER BY movies.title, movies.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListMoviesByTitleDesc :many
+-- Like ListMoviesByID, in the descending order of the title then the id.
+-- Without a cursor, the bound is the greatest title, since there is no greatest text.
+SELECT sqlc.embed(movies), movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies
+WHERE (movies.title, movies.id) < (coalesce(sqlc.narg('cursor_text')::text, (SELECT max(title) FROM movies)), coalesce(sqlc.narg('cursor_id')::bigint, 9223372036854775807))
+ORDER BY movies.title DESC, movies.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListMoviesByPublishYear :many
+-- Like ListMoviesByID, in the order of the publish year then the id.
+SELECT sqlc.embed(movies), movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies
+WHERE (movies.publish_year, movies.id) > (coalesce(sqlc.narg('cursor_int')::integer, 0), coalesce(sqlc.narg('cursor_id')::bigint, 0))
+ORDER BY movies.publish_year, movies.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListMoviesByPublishYearDesc :many
+-- Like ListMoviesByID, in the descending order of the publish year then the id.
+SELECT sqlc.embed(movies), movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies
+WHERE (movies.publish_year, movies.id) < (coalesce(sqlc.narg('cursor_int')::integer, 2147483647), coalesce(sqlc.narg('cursor_id')::bigint, 9223372036854775807))
+ORDER BY movies.publish_year DESC, movies.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListMoviesByRuntime :many
+-- Like ListMoviesByID, in the order of the runtime then the id.
+SELECT sqlc.embed(movies), movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies
+WHERE (movies.runtime, movies.id) > (coalesce(sqlc.narg('cursor_int')::integer, 0), coalesce(sqlc.narg('cursor_id')::bigint, 0))
+ORDER BY movies.runtime, movies.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListMoviesByRuntimeDesc :many
+-- Like ListMoviesByID, in the descending order of the runtime then the id.
+SELECT sqlc.embed(movies), movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies
+WHERE (movies.runtime, movies.id) < (coalesce(sqlc.narg('cursor_int')::integer, 2147483647), coalesce(sqlc.narg('cursor_id')::bigint, 9223372036854775807))
+ORDER BY movies.runtime DESC, movies.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListMoviesByRating :many
+-- Like ListMoviesByID, in the order of the rating then the id.
+SELECT sqlc.embed(movies), movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies
+WHERE (movies.average_rating, movies.id) > (coalesce(sqlc.narg('cursor_float')::float8, '-Infinity'), coalesce(sqlc.narg('cursor_id')::bigint, 0))
+ORDER BY movies.average_rating, movies.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListMoviesByRatingDesc :many
+-- Like ListMoviesByID, in the descending order of the rating then the id.
+SELECT sqlc.embed(movies), movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies
+WHERE (movies.average_rating, movies.id) < (coalesce(sqlc.narg('cursor_float')::float8, 'Infinity'), coalesce(sqlc.narg('cursor_id')::bigint, 9223372036854775807))
+ORDER BY movies.average_rating DESC, movies.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListMoviesByRelevance :many
+-- Like ListMoviesByID, in the order of the match score then the id.
+SELECT sqlc.embed(movies), search.match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies, LATERAL (
+    SELECT movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+) AS search
+WHERE (search.match_score, movies.id) > (coalesce(sqlc.narg('cursor_float')::float8, '-Infinity'), coalesce(sqlc.narg('cursor_id')::bigint, 0))
+ORDER BY search.match_score, movies.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListMoviesByRelevanceDesc :many
+-- Like ListMoviesByID, in the descending order of the match score then the id.
+SELECT sqlc.embed(movies), search.match_score
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+) AS movies, LATERAL (
+    SELECT movie_match_score(sqlc.arg('title'), sqlc.arg('fuzzy'), movies.title) AS match_score
+) AS search
+WHERE (search.match_score, movies.id) < (coalesce(sqlc.narg('cursor_float')::float8, 'Infinity'), coalesce(sqlc.narg('cursor_id')::bigint, 9223372036854775807))
+ORDER BY search.match_score DESC, movies.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountMoviesWithFilters :one
+SELECT count(*)
+FROM filtered_movies(
+    sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+    sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+    sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+    sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+);
+
+-- name: CountMovieFacets :many
+-- All the facets are counted over the movies matching the filters, and the callers keep the ones they need.
+-- The values of the decade facet are their first year, and the values of the runtime facet are buckets of minutes.
+WITH filtered AS (
+    SELECT genres, publish_year, runtime
+    FROM filtered_movies(
+        sqlc.arg('title'), sqlc.arg('fuzzy'), sqlc.arg('genres'), sqlc.arg('genres_any'), sqlc.arg('exclude_genres'),
+        sqlc.narg('year_min'), sqlc.narg('year_max'), sqlc.narg('runtime_min'), sqlc.narg('runtime_max'),
+        sqlc.narg('created_after'), sqlc.narg('created_before'), sqlc.narg('person_id'),
+        sqlc.arg('user_id'), sqlc.narg('in_watchlist'), sqlc.narg('watched')
+    )
+)
+SELECT 'genres'::text AS facet, genre::text AS value, count(*) AS count
+FROM filtered, unnest(filtered.genres) AS genre
+GROUP BY genre
+UNION ALL
+SELECT 'decade'::text AS facet, (publish_year / 10 * 10)::text AS value, count(*) AS count
+FROM filtered
+GROUP BY publish_year / 10 * 10
+UNION ALL
+SELECT 'runtime'::text AS facet, runtime_bucket AS value, count(*) AS count
+FROM (
+    SELECT CASE
+        WHEN runtime < 90 THEN '0-89'
+        WHEN runtime < 120 THEN '90-119'
+        WHEN runtime < 150 THEN '120-149'
+        ELSE '150+'
+    END AS runtime_bucket
+    FROM filtered
+) AS buckets
+GROUP BY runtime_bucket
+ORDER BY facet, count DESC, value
\ No newline at end of file
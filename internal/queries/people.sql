@@ -0,0 +1,30 @@
+-- name: CreatePerson :one
+INSERT INTO people (name, birth_year, biography)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetPerson :one
+SELECT *
+FROM people
+WHERE id = $1;
+
+-- name: ListPeople :many
+SELECT count(*) OVER() as total_records, sqlc.embed(people)
+FROM people
+WHERE (to_tsvector('simple', name) @@ plainto_tsquery('simple', sqlc.arg('name')) OR sqlc.arg('name') = '')
+ORDER BY name, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: UpdatePerson :one
+UPDATE people
+SET
+    name = coalesce(sqlc.narg('name'), name),
+    birth_year = coalesce(sqlc.narg('birth_year'), birth_year),
+    biography = coalesce(sqlc.narg('biography'), biography),
+    version = version + 1
+WHERE id = sqlc.arg('id') AND version = sqlc.arg('version')
+RETURNING *;
+
+-- name: DeletePerson :execrows
+DELETE FROM people
+WHERE id = $1;
\ No newline at end of file
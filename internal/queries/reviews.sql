@@ -0,0 +1,56 @@
+-- name: CreateReview :one
+INSERT INTO reviews (movie_id, user_id, score, body)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetReview :one
+SELECT *
+FROM reviews
+WHERE id = $1;
+
+-- name: ListMovieReviews :many
+SELECT count(*) OVER() as total_records, sqlc.embed(reviews)
+FROM reviews
+WHERE movie_id = sqlc.arg('movie_id')
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: UpdateReview :one
+UPDATE reviews
+SET
+    score = coalesce(sqlc.narg('score'), score),
+    body = coalesce(sqlc.narg('body'), body),
+    updated_at = now(),
+    version = version + 1
+WHERE id = sqlc.arg('id') AND version = sqlc.arg('version')
+RETURNING *;
+
+-- name: DeleteReview :execrows
+DELETE FROM reviews
+WHERE id = $1;
+
+-- name: LockMovie :one
+SELECT id
+FROM movies
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: UpdateMovieRatings :exec
+UPDATE movies
+SET (average_rating, review_count) = (
+    SELECT coalesce(round(avg(score), 2), 0)::double precision, count(*)
+    FROM reviews
+    WHERE reviews.movie_id = movies.id
+)
+WHERE id = $1;
+
+-- name: ListLatestMovieReviews :many
+-- The most recent reviews of each of the movies, at most per_movie of them.
+SELECT id, movie_id, user_id, score, body, version, created_at, updated_at
+FROM (
+    SELECT *, row_number() OVER (PARTITION BY movie_id ORDER BY created_at DESC, id DESC) AS rank
+    FROM reviews
+    WHERE movie_id = ANY(sqlc.arg('movie_ids')::bigint[])
+) AS ranked_reviews
+WHERE rank <= sqlc.arg('per_movie')::integer
+ORDER BY movie_id, created_at DESC, id DESC;
\ No newline at end of file
@@ -0,0 +1,61 @@
+-- name: AddToWatchlist :one
+-- The movie is added at the end of the watchlist.
+INSERT INTO watchlist_items (user_id, movie_id, position)
+SELECT sqlc.arg('user_id')::bigint, sqlc.arg('movie_id')::bigint, coalesce(max(position), 0) + 1
+FROM watchlist_items
+WHERE user_id = sqlc.arg('user_id')
+RETURNING *;
+
+-- name: RemoveFromWatchlist :execrows
+DELETE FROM watchlist_items
+WHERE user_id = $1 AND movie_id = $2;
+
+-- name: ListWatchlist :many
+-- The deleted movies are left out.
+SELECT count(*) OVER() as total_records, sqlc.embed(watchlist_items), sqlc.embed(movies)
+FROM watchlist_items
+JOIN movies ON movies.id = watchlist_items.movie_id
+WHERE watchlist_items.user_id = sqlc.arg('user_id') AND movies.deleted_at IS NULL
+ORDER BY watchlist_items.position, watchlist_items.added_at, watchlist_items.movie_id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: LockWatchlistOwner :exec
+-- Lock the user who owns a watchlist, so that the changes to the positions of its items happen one after another,
+-- even while the watchlist is empty.
+SELECT id
+FROM users
+WHERE id = $1
+FOR NO KEY UPDATE;
+
+-- name: LockWatchlist :many
+-- Lock the items of the watchlist of a user, and return the IDs of their movies in their order.
+SELECT movie_id
+FROM watchlist_items
+WHERE user_id = $1
+ORDER BY position, added_at, movie_id
+FOR UPDATE;
+
+-- name: SetWatchlistPositions :exec
+-- The movies of the watchlist are given the positions of their IDs in movie_ids, starting from 1.
+UPDATE watchlist_items
+SET position = ordered.position
+FROM unnest(sqlc.arg('movie_ids')::bigint[]) WITH ORDINALITY AS ordered(movie_id, position)
+WHERE watchlist_items.user_id = sqlc.arg('user_id') AND watchlist_items.movie_id = ordered.movie_id;
+
+-- name: CreateWatchedMovie :one
+INSERT INTO watched_movies (user_id, movie_id, watched_on)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListWatchedMovies :many
+-- The most recent viewings come first. The deleted movies are left out.
+SELECT count(*) OVER() as total_records, sqlc.embed(watched_movies), sqlc.embed(movies)
+FROM watched_movies
+JOIN movies ON movies.id = watched_movies.movie_id
+WHERE watched_movies.user_id = sqlc.arg('user_id') AND movies.deleted_at IS NULL
+ORDER BY watched_movies.watched_on DESC, watched_movies.id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: DeleteWatchedMovie :execrows
+DELETE FROM watched_movies
+WHERE id = $1 AND user_id = $2;
\ No newline at end of file
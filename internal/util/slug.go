@@ -0,0 +1,32 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Slugify returns the lowercase value with the runs of other characters than letters and digits
+// replaced by a "-", for example "Science Fiction" becomes "science-fiction" and "Ciencia Ficción"
+// becomes "ciencia-ficción". The letters and digits of every script are kept, so the slug is only empty
+// when the value has none of them.
+//
+// It must stay in sync with the normalization of the genres done by the migrations.
+func Slugify(value string) string {
+	var b strings.Builder
+
+	pendingDash := false
+	for _, r := range strings.ToLower(value) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingDash = false
+			b.WriteRune(r)
+			continue
+		}
+
+		pendingDash = true
+	}
+
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package util
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Science Fiction", "science-fiction"},
+		{"sci-fi", "sci-fi"},
+		{"  Film--Noir  ", "film-noir"},
+		{"Rock & Roll!", "rock-roll"},
+		{"2001: A Space Odyssey", "2001-a-space-odyssey"},
+		{"Ciencia Ficción", "ciencia-ficción"},
+		{"ÉPOUVANTE", "épouvante"},
+		{"Научная фантастика", "научная-фантастика"},
+		{"アニメ", "アニメ"},
+		{"剧情 / 爱情", "剧情-爱情"},
+		{"٣ أفلام", "٣-أفلام"},
+		{"!!!", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := Slugify(tt.value); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package validator
+
+import (
+	"errors"
+
+	"github.com/katatrina/greenlight/internal/util"
+)
+
+func ValidateGenreName(value string) error {
+	if err := ValidateStringLength(value, 1, 50); err != nil {
+		return err
+	}
+
+	// The slug of a genre is derived from its name, and must not be empty.
+	if util.Slugify(value) == "" {
+		return errors.New("must contain at least one letter or number")
+	}
+
+	return nil
+}
+
+func ValidateGenreSlug(value string) error {
+	if err := ValidateStringLength(value, 1, 50); err != nil {
+		return err
+	}
+
+	if util.Slugify(value) != value {
+		return errors.New("must contain only lowercase letters, numbers, and single dashes between them")
+	}
+
+	return nil
+}
+
+func ValidateGenreAliases(aliases []string) error {
+	if len(aliases) > 10 {
+		return errors.New("must not contain more than 10 aliases")
+	}
+
+	for _, alias := range aliases {
+		if err := ValidateGenreSlug(alias); err != nil {
+			return errors.New("must only contain slugs: lowercase letters, numbers, and single dashes between them")
+		}
+	}
+
+	return nil
+}
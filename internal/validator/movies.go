@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"regexp"
 	"time"
+
+	"github.com/katatrina/greenlight/internal/util"
 )
 
 var (
@@ -45,11 +47,18 @@ func ValidateMovieGenres(genres []string) error {
 		return errors.New("must contain between 1 and 5 genres")
 	}
 
-	firstGenre := genres[0]
-	for i := 1; i < len(genres); i++ {
-		if genres[i] == firstGenre {
+	// The genres are compared by their slugs, so that "Sci-Fi" and "sci fi" are the same genre.
+	seen := make(map[string]bool, len(genres))
+	for _, genre := range genres {
+		slug := util.Slugify(genre)
+		if slug == "" {
+			return errors.New("must not contain empty genres")
+		}
+
+		if seen[slug] {
 			return errors.New("must not contain duplicate genres")
 		}
+		seen[slug] = true
 	}
 
 	return nil
@@ -0,0 +1,46 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/katatrina/greenlight/internal/util"
+)
+
+func ValidatePersonName(value string) error {
+	return ValidateStringLength(value, 1, 200)
+}
+
+func ValidatePersonBirthYear(value int32) error {
+	now := time.Now()
+	if value < 1800 || value > int32(now.Year()) {
+		return fmt.Errorf("must be between 1800 and %d", now.Year())
+	}
+
+	return nil
+}
+
+func ValidatePersonBiography(value string) error {
+	return ValidateStringLength(value, 1, 5000)
+}
+
+func ValidateCreditRole(value string) error {
+	if !util.PermittedValue(value, "director", "writer", "actor") {
+		return errors.New("must be either director, writer or actor")
+	}
+
+	return nil
+}
+
+func ValidateCreditCharacterName(value string) error {
+	return ValidateStringLength(value, 1, 200)
+}
+
+func ValidateCreditBillingOrder(value int32) error {
+	if value < 0 || value > 1000 {
+		return errors.New("must be between 0 and 1000")
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package validator
+
+import "errors"
+
+func ValidateReviewScore(value int32) error {
+	if value < 1 || value > 10 {
+		return errors.New("must be between 1 and 10")
+	}
+
+	return nil
+}
+
+func ValidateReviewBody(value string) error {
+	return ValidateStringLength(value, 1, 2000)
+}
@@ -0,0 +1,9 @@
+DELETE FROM permissions WHERE code = 'reviews:write';
+
+DROP INDEX IF EXISTS movies_average_rating_idx;
+
+ALTER TABLE movies
+DROP COLUMN IF EXISTS average_rating,
+DROP COLUMN IF EXISTS review_count;
+
+DROP TABLE IF EXISTS reviews;
\ No newline at end of file
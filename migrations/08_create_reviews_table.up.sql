@@ -0,0 +1,28 @@
+CREATE TABLE reviews (
+    id bigserial PRIMARY KEY,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    score integer NOT NULL,
+    body text,
+    version integer NOT NULL DEFAULT 1,
+    created_at timestamptz(0) NOT NULL DEFAULT NOW(),
+    updated_at timestamptz(0) NOT NULL DEFAULT NOW(),
+    CONSTRAINT reviews_score_check CHECK (score BETWEEN 1 AND 10),
+    CONSTRAINT reviews_movie_id_user_id_key UNIQUE (movie_id, user_id)
+);
+
+-- The aggregates are kept on the movies, so that they can be returned and sorted on cheaply.
+ALTER TABLE movies
+ADD COLUMN average_rating double precision NOT NULL DEFAULT 0,
+ADD COLUMN review_count integer NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS movies_average_rating_idx ON movies (average_rating);
+
+INSERT INTO permissions (code)
+VALUES
+    ('reviews:write');
+
+-- Grant the new permission to the existing users, like it is granted to the new ones.
+INSERT INTO users_permissions (user_id, permission_id)
+SELECT users.id, permissions.id FROM users, permissions
+    WHERE permissions.code = 'reviews:write';
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE movies ADD COLUMN deleted_at timestamptz(0);
+
+-- Only the deleted movies are looked up by their deletion time, in the trash and by the purge job.
+CREATE INDEX IF NOT EXISTS movies_deleted_at_idx ON movies (deleted_at) WHERE deleted_at IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE movie_revisions (
+    id bigserial PRIMARY KEY,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    version integer NOT NULL,
+    action text NOT NULL,
+    snapshot jsonb NOT NULL,
+    user_id bigint REFERENCES users ON DELETE SET NULL,
+    created_at timestamptz(0) NOT NULL DEFAULT NOW(),
+    CONSTRAINT movie_revisions_movie_id_version_key UNIQUE (movie_id, version)
+);
+
+-- The history of the existing movies starts from their current version.
+INSERT INTO movie_revisions (movie_id, version, action, snapshot)
+SELECT id, version, 'create', jsonb_build_object('title', title, 'publish_year', publish_year, 'runtime', runtime, 'genres', genres)
+FROM movies;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE code = 'genres:write';
+
+DROP TABLE IF EXISTS genres;
\ No newline at end of file
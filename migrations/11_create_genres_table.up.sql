@@ -0,0 +1,30 @@
+CREATE TABLE genres (
+    id bigserial PRIMARY KEY,
+    slug text UNIQUE NOT NULL,
+    name text NOT NULL,
+    aliases text[] NOT NULL DEFAULT '{}',
+    created_at timestamptz(0) NOT NULL DEFAULT NOW()
+);
+
+-- Normalize the genres of the movies to slugs: lowercase, with the runs of other characters
+-- than letters and digits replaced by a "-". The duplicates are removed, keeping the original order.
+UPDATE movies
+SET genres = ARRAY(
+    SELECT slug
+    FROM (
+        SELECT trim(BOTH '-' FROM regexp_replace(lower(genre), '[^[:alnum:]]+', '-', 'g')) AS slug, ordinality
+        FROM unnest(movies.genres) WITH ORDINALITY AS genre
+    ) AS normalized
+    WHERE slug <> ''
+    GROUP BY slug
+    ORDER BY min(ordinality)
+);
+
+-- Seed the catalogue with the genres in use.
+INSERT INTO genres (slug, name)
+SELECT DISTINCT slug, initcap(replace(slug, '-', ' '))
+FROM movies, unnest(genres) AS slug;
+
+INSERT INTO permissions (code)
+VALUES
+    ('genres:write');
\ No newline at end of file
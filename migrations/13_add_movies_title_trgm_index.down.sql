@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS movies_title_trgm_idx;
+
+DROP EXTENSION IF EXISTS pg_trgm;
\ No newline at end of file
@@ -0,0 +1,24 @@
+CREATE TABLE people (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL,
+    birth_year integer,
+    biography text,
+    version integer NOT NULL DEFAULT 1,
+    created_at timestamptz(0) NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS people_name_idx ON people USING GIN (to_tsvector('simple', name));
+
+-- A person can't be deleted while credited in a movie, but the credits of a movie are deleted along with it.
+CREATE TABLE movie_credits (
+    id bigserial PRIMARY KEY,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    person_id bigint NOT NULL REFERENCES people ON DELETE RESTRICT,
+    role text NOT NULL,
+    character_name text,
+    billing_order integer NOT NULL DEFAULT 0,
+    CONSTRAINT movie_credits_role_check CHECK (role IN ('director', 'writer', 'actor')),
+    CONSTRAINT movie_credits_movie_id_person_id_role_key UNIQUE (movie_id, person_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS movie_credits_person_id_idx ON movie_credits (person_id);
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- The files of the images are kept in the blob store, under blob_key.
+-- The thumbnail_key is NULL until the thumbnail has been generated.
+CREATE TABLE movie_images (
+    id bigserial PRIMARY KEY,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    kind text NOT NULL,
+    content_type text NOT NULL,
+    size bigint NOT NULL,
+    width integer NOT NULL,
+    height integer NOT NULL,
+    blob_key text NOT NULL,
+    thumbnail_key text,
+    created_at timestamptz(0) NOT NULL DEFAULT NOW(),
+    CONSTRAINT movie_images_kind_check CHECK (kind IN ('poster', 'backdrop'))
+);
+
+CREATE INDEX IF NOT EXISTS movie_images_movie_id_idx ON movie_images (movie_id);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS watched_movies;
+
+DROP TABLE IF EXISTS watchlist_items;
\ No newline at end of file
@@ -0,0 +1,21 @@
+-- The movies of a watchlist are ordered by their position, the lowest first.
+CREATE TABLE watchlist_items (
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    position integer NOT NULL,
+    added_at timestamptz(0) NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS watchlist_items_user_id_position_idx ON watchlist_items (user_id, position);
+
+-- A movie can be watched several times, so each viewing has its own entry.
+CREATE TABLE watched_movies (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    movie_id bigint NOT NULL REFERENCES movies ON DELETE CASCADE,
+    watched_on date NOT NULL,
+    created_at timestamptz(0) NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS watched_movies_user_id_watched_on_idx ON watched_movies (user_id, watched_on);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP FUNCTION IF EXISTS movie_match_score;
+DROP FUNCTION IF EXISTS filtered_movies;
@@ -0,0 +1,66 @@
+-- filtered_movies returns the movies which are not deleted and match the filters of the movie lists.
+-- It's the only definition of those filters, which all the queries listing, counting or exporting the movies call.
+-- An empty title or genres array, or a NULL bound, means no filter.
+--
+-- Since it's a STABLE SQL function made of a single SELECT, the planner inlines it in the queries,
+-- which can still use the indexes of the movies. In its body, the arguments are prefixed by the name
+-- of the function, because the columns of the same name would take precedence.
+CREATE OR REPLACE FUNCTION filtered_movies(
+    title text,
+    fuzzy boolean,
+    genres text[],
+    genres_any boolean,
+    exclude_genres text[],
+    year_min integer,
+    year_max integer,
+    runtime_min integer,
+    runtime_max integer,
+    created_after timestamptz,
+    created_before timestamptz,
+    person_id bigint,
+    user_id bigint,
+    in_watchlist boolean,
+    watched boolean
+) RETURNS SETOF movies
+LANGUAGE sql STABLE
+AS $$
+    SELECT *
+    FROM movies
+    WHERE movies.deleted_at IS NULL
+    -- The full-text search only matches whole words, while the fuzzy search also matches misspelled or partial words.
+    -- Both can use an index of the title.
+    AND (filtered_movies.title = ''
+        OR (NOT filtered_movies.fuzzy AND to_tsvector('simple', movies.title) @@ plainto_tsquery('simple', filtered_movies.title))
+        OR (filtered_movies.fuzzy AND filtered_movies.title <% movies.title))
+    -- The movies have either all or any of the genres. Both operators can use the GIN index of the genres.
+    AND (filtered_movies.genres = '{}'
+        OR (NOT filtered_movies.genres_any AND movies.genres @> filtered_movies.genres)
+        OR (filtered_movies.genres_any AND movies.genres && filtered_movies.genres))
+    AND (filtered_movies.exclude_genres = '{}' OR NOT (movies.genres && filtered_movies.exclude_genres))
+    -- The bounds of the ranges are inclusive.
+    AND (filtered_movies.year_min IS NULL OR movies.publish_year >= filtered_movies.year_min)
+    AND (filtered_movies.year_max IS NULL OR movies.publish_year <= filtered_movies.year_max)
+    AND (filtered_movies.runtime_min IS NULL OR movies.runtime >= filtered_movies.runtime_min)
+    AND (filtered_movies.runtime_max IS NULL OR movies.runtime <= filtered_movies.runtime_max)
+    AND (filtered_movies.created_after IS NULL OR movies.created_at >= filtered_movies.created_after)
+    AND (filtered_movies.created_before IS NULL OR movies.created_at <= filtered_movies.created_before)
+    AND (filtered_movies.person_id IS NULL
+        OR movies.id IN (SELECT movie_id FROM movie_credits WHERE movie_credits.person_id = filtered_movies.person_id))
+    -- The watchlist and watched filters are relative to the user given by user_id.
+    AND (filtered_movies.in_watchlist IS NULL
+        OR (movies.id IN (SELECT movie_id FROM watchlist_items WHERE watchlist_items.user_id = filtered_movies.user_id)) = filtered_movies.in_watchlist)
+    AND (filtered_movies.watched IS NULL
+        OR (movies.id IN (SELECT movie_id FROM watched_movies WHERE watched_movies.user_id = filtered_movies.user_id)) = filtered_movies.watched)
+$$;
+
+-- movie_match_score returns how well the title of a movie matches the searched title: the full-text rank
+-- of the title, or its trigram similarity for the fuzzy search. It's 0 without a searched title.
+CREATE OR REPLACE FUNCTION movie_match_score(title text, fuzzy boolean, movie_title text) RETURNS float8
+LANGUAGE sql STABLE
+AS $$
+    SELECT (CASE
+        WHEN title = '' THEN 0
+        WHEN fuzzy THEN word_similarity(title, movie_title)
+        ELSE ts_rank(to_tsvector('simple', movie_title), plainto_tsquery('simple', title))
+    END)::float8
+$$;
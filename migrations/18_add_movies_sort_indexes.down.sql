@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS movies_title_id_idx;
+DROP INDEX IF EXISTS movies_publish_year_id_idx;
+DROP INDEX IF EXISTS movies_runtime_id_idx;
+DROP INDEX IF EXISTS movies_average_rating_id_idx;
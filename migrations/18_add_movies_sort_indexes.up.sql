@@ -0,0 +1,6 @@
+-- The movie lists are paginated by keyset on their sort key then the id, in both directions,
+-- so their queries compare and sort the movies on (key, id), as these indexes do.
+CREATE INDEX IF NOT EXISTS movies_title_id_idx ON movies (title, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS movies_publish_year_id_idx ON movies (publish_year, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS movies_runtime_id_idx ON movies (runtime, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS movies_average_rating_id_idx ON movies (average_rating, id) WHERE deleted_at IS NULL;